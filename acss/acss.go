@@ -2,18 +2,33 @@ package acss
 
 import (
 	"fmt"
-	"math/rand"
+	"hash/fnv"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
+	"github.com/gascore/gasx"
 	"github.com/gascore/gasx/html"
 )
 
 var styleRgxp = regexp.MustCompile(`([a-zA-Z]*){(.*?)}(:[a-z]*|)(@([a-z]*)|)`)
 
+// artifactKey key of file classes in gasx.BlockInfo artifacts, a line per class: id and quoted acss attribute
+const artifactKey = "acss"
+
+// Generator atomic css generator. Safe for concurrent use.
 type Generator struct {
+	// Styles generated styles, use GetStyles while compilation is running
 	Styles strings.Builder
+	mu     sync.Mutex
+
+	// generated classes which styles are in Styles
+	generated map[string]bool
+
+	// registered styles are collected by builder in files order
+	registered bool
 
 	Exceptions  []string
 	BreakPoints map[string]string
@@ -30,6 +45,21 @@ func (g *Generator) Init() {
 	}
 }
 
+// Configure set breakpoints, custom values (tokens) and exceptions from project config
+func (g *Generator) Configure(cfg gasx.ACSSConfig) {
+	g.Init()
+
+	for name, media := range cfg.Breakpoints {
+		g.BreakPoints[name] = media
+	}
+
+	for name, value := range cfg.Tokens {
+		g.Custom[name] = value
+	}
+
+	g.Exceptions = append(g.Exceptions, cfg.Exceptions...)
+}
+
 type acssStyle struct {
 	body        string
 	media       string
@@ -37,25 +67,111 @@ type acssStyle struct {
 }
 
 func (g *Generator) OnElementInfo() func(*html.ElementInfo) {
-	rand.Seed(time.Now().UnixNano())
 	return func(info *html.ElementInfo) {
 		acssAttr := info.Attrs["acss"]
 		if acssAttr == "" {
 			return
 		}
 
-		classID   := "A" + randID(8)
-		outStyles := g.GenCSS(classID, acssAttr)
-		
+		if info.Block != nil {
+			if enabled, _ := info.Block.Arg("acss"); enabled == "false" { // $html(acss=false)
+				return
+			}
+		}
+
+		classID := "A" + hashID(acssAttr, 8)
+
 		delete(info.Attrs, "acss")
 		info.Attrs["data-acss"] = acssAttr
 		info.Attrs["data-acss-id"] = classID
 		info.Attrs["class"] += " " + classID
 
-		g.Styles.WriteString(outStyles)
+		if info.Block != nil && info.Block.Artifacts != nil {
+			class := classID + " " + strconv.Quote(acssAttr) + "\n"
+			if !strings.Contains(info.Block.Artifacts[artifactKey], class) {
+				info.Block.Artifacts[artifactKey] += class
+			}
+		}
+
+		// without builder styles are written in elements order
+		if !g.registered {
+			g.addStyles(classID, acssAttr)
+		}
 	}
 }
 
+// addStyles generate styles of class if they aren't generated yet
+func (g *Generator) addStyles(classID, acssAttr string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.generated[classID] {
+		return
+	}
+
+	if g.generated == nil {
+		g.generated = make(map[string]bool)
+	}
+	g.generated[classID] = true
+
+	g.Styles.WriteString(g.GenCSS(classID, acssAttr))
+}
+
+// Register declare "acss" argument of html blocks, add generator cache key and artifact collector to builder
+func (g *Generator) Register(builder *gasx.Builder) {
+	for _, name := range html.BlockNames {
+		builder.DeclareArgs(name, gasx.BlockArgSpec{
+			Name:   "acss",
+			Values: []string{"true", "false"},
+			Usage:  "generate atomic css for elements in the block",
+		})
+	}
+
+	builder.AddCacheKey(g.CacheKey)
+	builder.ArtifactCollectors = append(builder.ArtifactCollectors, g.Collect())
+	g.registered = true
+}
+
+// Collect return gasx.Builder artifact collector adding styles of file classes,
+// so styles are in files order whatever the number of workers is
+func (g *Generator) Collect() func(gasx.File, map[string]string) {
+	return func(file gasx.File, artifacts map[string]string) {
+		for _, line := range strings.Split(artifacts[artifactKey], "\n") {
+			space := strings.IndexByte(line, ' ')
+			if space == -1 {
+				continue
+			}
+
+			acssAttr, err := strconv.Unquote(line[space+1:])
+			if err != nil {
+				continue
+			}
+
+			g.addStyles(line[:space], acssAttr)
+		}
+	}
+}
+
+// CacheKey return generator configuration for gasx.Builder cache key
+func (g *Generator) CacheKey() string {
+	return "acss/2:" + fmt.Sprint(g.Exceptions) + sortedMap(g.BreakPoints) + sortedMap(g.Custom)
+}
+
+func sortedMap(m map[string]string) string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := strings.Builder{}
+	for _, key := range keys {
+		out.WriteString(fmt.Sprintf("%q:%q;", key, m[key]))
+	}
+
+	return "{" + out.String() + "}"
+}
+
 func (g *Generator) GenCSS(classID, acssAttr string) string {
 	id := fmt.Sprintf(".%s, [data-acss-id=\"%[1]s\"] ", classID)
 	idWithP := func(p string) string {
@@ -191,10 +307,16 @@ func (g *Generator) GenCSS(classID, acssAttr string) string {
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
 
-func randID(n int) string {
+// hashID return id depending on value only, so compiled files are the same on every build
+func hashID(value string, n int) string {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	sum := h.Sum64()
+
 	b := make([]rune, n)
 	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
+		b[i] = letters[sum%uint64(len(letters))]
+		sum /= uint64(len(letters))
 	}
 	return string(b)
 }
@@ -202,8 +324,19 @@ func randID(n int) string {
 func (g *Generator) GetStyles() string {
 	// Some logic?
 
+	g.mu.Lock()
 	out := g.Styles.String()
 	g.Styles.Reset()
+	g.generated = nil
+	g.mu.Unlock()
 
 	return out
 }
+
+// Reset remove generated styles, call it before every build so styles of failed build aren't kept
+func (g *Generator) Reset() {
+	g.mu.Lock()
+	g.Styles.Reset()
+	g.generated = nil
+	g.mu.Unlock()
+}
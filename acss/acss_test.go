@@ -0,0 +1,89 @@
+package acss
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gascore/gasx"
+	"github.com/gascore/gasx/html"
+)
+
+// buildStyles compile files with acss classes and return generated styles
+func buildStyles(t *testing.T, workers int, fsys gasx.WriteFS, files []gasx.File) string {
+	builder := &gasx.Builder{FS: fsys, Workers: workers, CacheDir: ".cache"}
+
+	generator := &Generator{}
+	generator.Init()
+
+	compiler := html.NewCompiler()
+	compiler.AddOnElementInfo(generator.OnElementInfo())
+	compiler.Register(builder)
+	generator.Register(builder)
+
+	err := builder.ParseFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return generator.GetStyles()
+}
+
+func TestStylesOrder(t *testing.T) {
+	sources := make(map[string]string)
+	var files []gasx.File
+	for i := 0; i < 32; i++ {
+		name := fmt.Sprintf("app/file%02d.gos", i)
+		sources[name] = fmt.Sprintf("package app\n\nfunc F%d() interface{} {\n\treturn $html{<div acss=\"w{%dpx} c{red}\"><p acss=\"c{red}\"></p></div>}$\n}\n", i, i)
+		files = append(files, gasx.File{Path: name, Extension: "gos"})
+	}
+
+	want := buildStyles(t, 1, gasx.NewMemFS(sources), files)
+	if want == "" {
+		t.Fatal("no styles are generated")
+	}
+
+	for run := 0; run < 5; run++ {
+		fsys := gasx.NewMemFS(sources)
+		if got := buildStyles(t, 8, fsys, files); got != want {
+			t.Fatalf("styles of concurrent build differ from sequential one:\n%s\nwant:\n%s", got, want)
+		}
+
+		// files are restored from cache
+		if got := buildStyles(t, 8, fsys, files); got != want {
+			t.Fatalf("styles of cached build differ from sequential one:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	fsys := gasx.NewMemFS(map[string]string{
+		"app/a.gos": "package app\n\nfunc A() interface{} {\n\treturn $html{<div acss=\"c{red}\"></div>}$\n}\n",
+		"app/b.gos": "package app\n\nfunc B() interface{} {\n\treturn $unknown{x}$\n}\n",
+	})
+	builder := &gasx.Builder{FS: fsys}
+
+	generator := &Generator{}
+	generator.Init()
+
+	compiler := html.NewCompiler()
+	compiler.AddOnElementInfo(generator.OnElementInfo())
+	compiler.Register(builder)
+	generator.Register(builder)
+
+	// failed build, its styles aren't taken
+	if err := builder.ParseFiles([]gasx.File{{Path: "app/a.gos", Extension: "gos"}, {Path: "app/b.gos", Extension: "gos"}}); err == nil {
+		t.Fatal("expected error for unknown block")
+	}
+	if generator.Styles.Len() == 0 {
+		t.Fatal("styles of compiled file aren't generated")
+	}
+
+	generator.Reset()
+	if err := builder.ParseFiles(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if styles := generator.GetStyles(); styles != "" {
+		t.Errorf("styles of failed build are kept:\n%s", styles)
+	}
+}
@@ -0,0 +1,219 @@
+package gasx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var argKeyRgxp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// BlockArg special block argument: $name(key=value){ ... }$
+type BlockArg struct {
+	Key string
+
+	// Value unquoted argument value, "true" if argument has no value
+	Value string
+
+	// offset argument offset in GOS file
+	offset int
+}
+
+// BlockArgSpec argument accepted by block compiler
+type BlockArgSpec struct {
+	Name string
+
+	// Values allowed values, any value is allowed if empty
+	Values []string
+
+	Required bool
+
+	// Usage argument description for diagnostics
+	Usage string
+}
+
+// Arg return argument value
+func (info *BlockInfo) Arg(key string) (string, bool) {
+	for _, arg := range info.Args {
+		if arg.Key == key {
+			return arg.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// DeclareArgs add arguments accepted by blocks with name
+func (builder *Builder) DeclareArgs(name string, specs ...BlockArgSpec) {
+	if builder.ArgSpecs == nil {
+		builder.ArgSpecs = make(map[string][]BlockArgSpec)
+	}
+
+	builder.ArgSpecs[name] = append(builder.ArgSpecs[name], specs...)
+}
+
+// validateArgs check block arguments by declared specs. Returns diagnostic and index of invalid argument (-1 if it's about block).
+// Arguments aren't checked if block has no specs and compilers registry isn't used.
+func (builder *Builder) validateArgs(name string, args []BlockArg) (*Diagnostic, int) {
+	specs, declared := builder.ArgSpecs[name]
+	if !declared && len(builder.Compilers) == 0 {
+		return nil, -1
+	}
+
+	newDiagnostic := func(msg string) *Diagnostic {
+		return &Diagnostic{Block: name, Severity: SeverityError, Message: msg, Suggestion: argsUsage(specs)}
+	}
+
+	seen := make(map[string]bool)
+	for i, arg := range args {
+		if seen[arg.Key] {
+			return newDiagnostic("duplicate argument \"" + arg.Key + "\""), i
+		}
+		seen[arg.Key] = true
+
+		spec, ok := findArgSpec(specs, arg.Key)
+		if !ok {
+			return newDiagnostic("unknown argument \"" + arg.Key + "\""), i
+		}
+
+		if len(spec.Values) != 0 && !InArrayString(arg.Value, spec.Values) {
+			return newDiagnostic("invalid value \"" + arg.Value + "\" of argument \"" + arg.Key + "\", expected one of: " + strings.Join(spec.Values, ", ")), i
+		}
+	}
+
+	for _, spec := range specs {
+		if spec.Required && !seen[spec.Name] {
+			return newDiagnostic("missing required argument \"" + spec.Name + "\""), -1
+		}
+	}
+
+	return nil, -1
+}
+
+func findArgSpec(specs []BlockArgSpec, key string) (BlockArgSpec, bool) {
+	for _, spec := range specs {
+		if spec.Name == key {
+			return spec, true
+		}
+	}
+
+	return BlockArgSpec{}, false
+}
+
+// argsUsage return accepted arguments description
+func argsUsage(specs []BlockArgSpec) string {
+	if len(specs) == 0 {
+		return "block doesn't accept arguments"
+	}
+
+	var usage []string
+	for _, spec := range specs {
+		line := spec.Name
+		if len(spec.Values) != 0 {
+			line += "=" + strings.Join(spec.Values, "|")
+		}
+		if spec.Required {
+			line += " (required)"
+		}
+		if spec.Usage != "" {
+			line += " - " + spec.Usage
+		}
+
+		usage = append(usage, line)
+	}
+
+	return "accepted arguments:\n\t\t" + strings.Join(usage, "\n\t\t")
+}
+
+// parseBlockArgs parse arguments list "key=value, key2, key3=`value`" starting at offset in GOS file.
+// Values with commas, whitespace or quotes must be quoted: "double" (with Go escapes), 'single' or `raw`.
+// Returns offset of invalid argument with error.
+func parseBlockArgs(src string, start, end int) ([]BlockArg, int, string) {
+	var (
+		args      []BlockArg
+		partStart = start
+	)
+
+	for i := start; i <= end; i++ {
+		if i < end {
+			switch quote := src[i]; quote {
+			case '"', '\'', '`':
+				open := i
+				for i++; i < end && src[i] != quote; i++ {
+					if src[i] == '\\' && quote == '"' {
+						i++
+					}
+				}
+				if i >= end {
+					return nil, open, "unterminated quoted value"
+				}
+				continue
+			case ',':
+			default:
+				continue
+			}
+		}
+
+		part := src[partStart:i]
+		offset := partStart + len(part) - len(strings.TrimLeft(part, " \t\r\n"))
+		partStart = i + 1
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if i == end {
+				break // trailing comma
+			}
+			return nil, offset, "empty argument"
+		}
+
+		arg := BlockArg{Value: "true", offset: offset}
+
+		eq := strings.Index(part, "=")
+		if eq == -1 {
+			arg.Key = part
+		} else {
+			arg.Key = strings.TrimSpace(part[:eq])
+
+			value, msg := unquoteArgValue(strings.TrimSpace(part[eq+1:]))
+			if msg != "" {
+				return nil, offset, msg
+			}
+			arg.Value = value
+		}
+
+		if !argKeyRgxp.MatchString(arg.Key) {
+			return nil, offset, "invalid argument name \"" + arg.Key + "\""
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, 0, ""
+}
+
+// unquoteArgValue return argument value without quotes or error message
+func unquoteArgValue(value string) (string, string) {
+	if value == "" {
+		return "", ""
+	}
+
+	switch value[0] {
+	case '"', '`':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", "invalid argument value " + value
+		}
+		return unquoted, ""
+	case '\'':
+		if len(value) < 2 || strings.IndexByte(value[1:], '\'') != len(value)-2 {
+			return "", "invalid argument value " + value
+		}
+		return value[1 : len(value)-1], ""
+	}
+
+	if strings.ContainsAny(value, " \t\r\n\"'`") {
+		return "", "argument value " + value + " must be quoted"
+	}
+
+	return value, ""
+}
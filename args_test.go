@@ -0,0 +1,82 @@
+package gasx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBlockArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		want   []BlockArg
+		offset int
+		err    string
+	}{
+		{name: "empty", args: ""},
+		{
+			name: "flags and values",
+			args: "root=div, acss , x_y-z=1",
+			want: []BlockArg{{Key: "root", Value: "div"}, {Key: "acss", Value: "true", offset: 10}, {Key: "x_y-z", Value: "1", offset: 17}},
+		},
+		{
+			name: "trailing comma",
+			args: "a=1,",
+			want: []BlockArg{{Key: "a", Value: "1"}},
+		},
+		{
+			name: "double quoted",
+			args: `title="a, b", text="say \"hi\"\t"`,
+			want: []BlockArg{{Key: "title", Value: "a, b"}, {Key: "text", Value: "say \"hi\"\t", offset: 14}},
+		},
+		{
+			name: "single quoted",
+			args: `title='a "b" c', empty=''`,
+			want: []BlockArg{{Key: "title", Value: `a "b" c`}, {Key: "empty", Value: "", offset: 17}},
+		},
+		{
+			name: "raw quoted",
+			args: "re=`\\d+, 'x'`",
+			want: []BlockArg{{Key: "re", Value: `\d+, 'x'`}},
+		},
+		{
+			name: "empty value",
+			args: "a=",
+			want: []BlockArg{{Key: "a", Value: ""}},
+		},
+		{name: "empty argument", args: "a, ,b", offset: 3, err: "empty argument"},
+		{name: "invalid name", args: "a=1, 2b=3", offset: 5, err: `invalid argument name "2b"`},
+		{name: "quoted name", args: `"a"=1`, offset: 0, err: `invalid argument name ""a""`},
+		{name: "unterminated double quote", args: `a=1, b="x, c=2`, offset: 7, err: "unterminated quoted value"},
+		{name: "unterminated single quote", args: `b='x`, offset: 2, err: "unterminated quoted value"},
+		{name: "unterminated raw quote", args: "b=`x", offset: 2, err: "unterminated quoted value"},
+		{name: "text after quotes", args: `a="x" y`, offset: 0, err: `invalid argument value "x" y`},
+		{name: "unquoted whitespace", args: `a=b c`, offset: 0, err: "argument value b c must be quoted"},
+		{name: "unquoted quote", args: `a=b"c"`, offset: 0, err: `argument value b"c" must be quoted`},
+		{name: "invalid escape", args: `a="\q"`, offset: 0, err: `invalid argument value "\q"`},
+	}
+
+	for _, test := range tests {
+		// arguments are parsed in GOS file: "$html(...){"
+		src := "$html(" + test.args + "){"
+		args, offset, msg := parseBlockArgs(src, 6, 6+len(test.args))
+
+		if msg != test.err {
+			t.Errorf("%s: error %q, want %q", test.name, msg, test.err)
+			continue
+		}
+		if msg != "" {
+			if offset-6 != test.offset {
+				t.Errorf("%s: error offset %d, want %d", test.name, offset-6, test.offset)
+			}
+			continue
+		}
+
+		for i := range test.want {
+			test.want[i].offset += 6
+		}
+		if !reflect.DeepEqual(args, test.want) {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.name, args, test.want)
+		}
+	}
+}
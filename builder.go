@@ -1,21 +1,84 @@
 package gasx
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
-	"os/exec"
-	"strings"
+	"path"
 	"path/filepath"
+	"strings"
 
-	"github.com/fatih/color"
 	copyPkg "github.com/otiai10/copy"
 )
 
+// Version gasx version (part of compilation cache key)
+const Version = "0.1.0"
+
 // Builder GOS files builder
 type Builder struct {
-	// BlockCompilers pipeline of special blocks compilers
+	// Middlewares compilers called for every block before named compiler.
+	// Describe their configuration with AddCacheKey if cache is used.
+	Middlewares []BlockCompiler
+
+	// Compilers special blocks compilers by block name. If it isn't empty, blocks without compiler are errors.
+	Compilers map[string]BlockCompiler
+
+	// ArgSpecs arguments accepted by blocks by block name
+	ArgSpecs map[string][]BlockArgSpec
+
+	// BlockCompilers pipeline of special blocks compilers called for every block after named compiler
 	BlockCompilers []BlockCompiler
+
+	// BeforeFile hooks called after GOS file is read, they can change source and output path
+	BeforeFile []FileHook
+
+	// AfterCompile hooks called after GOS file is compiled, they can change compiled file and output path.
+	// They aren't called for files skipped by cache, cached output already has their changes.
+	AfterCompile []FileHook
+
+	// AfterWrite hooks called after compiled file is written or restored from cache (FileContext.Cached),
+	// in files order, even with Workers. Use them to collect per-file data.
+	AfterWrite []FileHook
+
+	// CacheDir directory for compilation cache manifest, cache is disabled if empty
+	CacheDir string
+
+	// CacheKeys pipeline configuration description (compilers, acss settings, e.t.c.), cache is invalidated when it changes
+	CacheKeys []string
+
+	// cacheKeys functions added by AddCacheKey
+	cacheKeys []func() string
+
+	// CacheRestorers receive artifacts of files skipped by cache
+	CacheRestorers []func(file File, artifacts map[string]string)
+
+	// ArtifactCollectors receive artifacts of every compiled or cached file in files order after it is written.
+	// Output combined from all files (e.g. styles) must be collected here, so it doesn't depend on Workers.
+	ArtifactCollectors []func(file File, artifacts map[string]string)
+
+	// OverlayDir directory for compiled files, sources are not modified.
+	// Build application with "go build -overlay" and Builder.OverlayFile().
+	OverlayDir string
+
+	// StaleDirs directories of OutFS where ParseFiles removes compiled files which sources don't exist anymore,
+	// stale files aren't removed if it is empty
+	StaleDirs []string
+
+	// Workers number of files compiled concurrently, files are compiled one by one if less than 2.
+	// BlockCompilers, BeforeFile and AfterCompile hooks must be safe for concurrent use.
+	Workers int
+
+	// Root host directory of FS and OutFS if they aren't OS, "/" if empty. Overlay file and line directives
+	// of compiled files in OverlayDir have absolute paths, names of OS file system are resolved from working directory.
+	Root string
+
+	// FS file system with GOS files, OS if nil
+	FS fs.FS
+
+	// OutFS file system for compiled files, compilation cache and overlay file.
+	// If nil, FS is used if it is WriteFS, otherwise OS.
+	OutFS WriteFS
 }
 
 // BlockInfo information about special block
@@ -26,21 +89,54 @@ type BlockInfo struct {
 	// Value special block value
 	Value string
 
+	// Args special block arguments
+	Args []BlockArg
+
 	// FileInfo isExternal, file path, extension, e.t.c.
 	FileInfo File
 
 	// FileBytes full GOS file value
 	FileBytes string
+
+	// Artifacts values produced by compilers for the whole file (styles, e.t.c.), stored in compilation cache
+	Artifacts map[string]string
+
+	// Imports packages imported by compiled block, added to compiled file
+	Imports []Import
+
+	// Decls top-level declarations added by compiled block to the end of compiled file
+	Decls []string
+
+	// lineDirective return line directive for offset in Value, nil if block isn't compiled by Builder
+	lineDirective func(offset int) string
 }
 
-// BlockCompiler node for render pipeline
+// BlockCompiler node for render pipeline. Compiler can return *Diagnostic as error.
 type BlockCompiler func(*BlockInfo) (string, error)
 
-// RenderBlock compile special block by render pipeline
+// RenderBlock compile special block by render pipeline: middlewares, named compiler and block compilers
 func (builder *Builder) RenderBlock(block *BlockInfo) (string, error) {
-	for i, renderer := range builder.BlockCompilers {
+	if diagnostic := builder.unknownBlock(block.Name); diagnostic != nil {
+		return "", diagnostic
+	}
+
+	if diagnostic, _ := builder.validateArgs(block.Name, block.Args); diagnostic != nil {
+		return "", diagnostic
+	}
+
+	pipeline := append([]BlockCompiler{}, builder.Middlewares...)
+	if compiler, ok := builder.Compilers[block.Name]; ok {
+		pipeline = append(pipeline, compiler)
+	}
+	pipeline = append(pipeline, builder.BlockCompilers...)
+
+	for i, renderer := range pipeline {
 		newVal, err := renderer(block)
 		if err != nil {
+			if diagnostic, ok := err.(*Diagnostic); ok {
+				return "", diagnostic
+			}
+
 			return "", fmt.Errorf("error in block renderer %d: \n%s", i, err.Error())
 		}
 
@@ -50,77 +146,122 @@ func (builder *Builder) RenderBlock(block *BlockInfo) (string, error) {
 	return block.Value, nil
 }
 
-// Log println message with "Builder" prefix
-func Log(msg string) {
-	fmt.Println(color.BlueString("Builder:") + " " + msg)
-}
-
-// Must compact "if err != nil"
+// Must compact "if err != nil", panics with error message. Use LogError in long-running processes.
 func Must(err error) {
 	if err != nil {
 		ErrorMsg(err.Error())
 	}
 }
 
-// ErrorMsg print message with ERROR tag
+// ErrorMsg print message with ERROR tag and panic. Use LogErrorMsg in long-running processes.
 func ErrorMsg(msg string) {
-	fmt.Println(color.RedString("ERROR") + ": " + msg)
-	panic(msg)
+	panic(LogErrorMsg(msg).Error())
 }
 
-// RunCommand execute command
+// RunCommand execute command, panics on error
 func RunCommand(command string) {
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	Must(cmd.Run())
+	Must(RunCommandE(command))
 }
 
-// NewFile create new file
+// RunCommandE execute command by sh with os stdout and stderr. Use Run for more options.
+func RunCommandE(command string) error {
+	_, err := Run(context.Background(), Shell(command))
+	return err
+}
+
+// NewFile create new file, panics on error
 func NewFile(path, body string) {
-	Must(ioutil.WriteFile(path, []byte(body), 0644))
+	Must(NewFileE(path, body))
 }
 
-// DeleteFile delete file
+// NewFileE create new file
+func NewFileE(path, body string) error {
+	return NewFileFS(OS, path, body)
+}
+
+// NewFileFS create new file in fsys
+func NewFileFS(fsys WriteFS, path, body string) error {
+	return fsys.WriteFile(path, []byte(body), 0644)
+}
+
+// DeleteFile delete file, panics on error
 func DeleteFile(path string) {
-	Must(os.Remove(path))
+	Must(DeleteFileE(path))
+}
+
+// DeleteFileE delete file
+func DeleteFileE(path string) error {
+	return OS.Remove(path)
 }
 
-// CopyFile copy file from pathA to file in pathB
+// CopyFile copy file from pathA to file in pathB, panics on error
 func CopyFile(pathA, pathB string) {
-	file, err := ioutil.ReadFile(pathA)
-	Must(err)
+	Must(CopyFileE(pathA, pathB))
+}
 
-	err = os.Remove(pathB)
-	if err != nil && !os.IsNotExist(err) {
-		Must(err)
+// CopyFileE copy file from pathA to file in pathB
+func CopyFileE(pathA, pathB string) error {
+	return CopyFileFS(OS, pathA, pathB)
+}
+
+// CopyFileFS copy file from pathA to file in pathB in fsys
+func CopyFileFS(fsys WriteFS, pathA, pathB string) error {
+	file, err := fs.ReadFile(fsys, pathA)
+	if err != nil {
+		return err
 	}
 
-	NewFile(pathB, string(file))
+	return NewFileFS(fsys, pathB, string(file))
 }
 
-// ClearDir reacreate directory or create if it doesn't exists
+// ClearDir reacreate directory or create if it doesn't exists, panics on error
 func ClearDir(dir string) {
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		Must(os.Mkdir(dir, os.ModePerm))
-	} else {
-		Must(os.RemoveAll(dir))
+	Must(ClearDirE(dir))
+}
+
+// ClearDirE reacreate directory or create if it doesn't exists
+func ClearDirE(dir string) error {
+	return ClearDirFS(OS, dir)
+}
+
+// ClearDirFS reacreate directory in fsys or create if it doesn't exists
+func ClearDirFS(fsys WriteFS, dir string) error {
+	if !ExistsFS(fsys, dir) {
+		return fsys.MkdirAll(dir, os.ModePerm)
 	}
+
+	return fsys.RemoveAll(dir)
 }
 
-// CopyDir copy dirA to dirB
+// CopyDir copy dirA to dirB, panics on error
 func CopyDir(dirA, dirB string) {
-	Must(copyPkg.Copy(dirA, dirB))
+	Must(CopyDirE(dirA, dirB))
+}
+
+// CopyDirE copy dirA to dirB
+func CopyDirE(dirA, dirB string) error {
+	return copyPkg.Copy(dirA, dirB)
+}
+
+// CopyDirFS copy dirA to dirB in fsys
+func CopyDirFS(fsys WriteFS, dirA, dirB string) error {
+	return fs.WalkDir(fsys, dirA, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := dirB + strings.TrimPrefix(path, dirA)
+		if entry.IsDir() {
+			return fsys.MkdirAll(target, os.ModePerm)
+		}
+
+		return CopyFileFS(fsys, path, target)
+	})
 }
 
 // Exists return true if file exisits
 func Exists(name string) bool {
-	if _, err := os.Stat(name); err != nil {
-		if os.IsNotExist(err) {
-			return false
-		}
-	}
-	return true
+	return ExistsFS(OS, name)
 }
 
 // InArrayString return is string in array of strings
@@ -136,14 +277,19 @@ func InArrayString(a string, arr []string) bool {
 
 // FilesByPattern return files path matched by mattern in directory
 func FilesByPattern(dir string, pattern string) ([]string, error) {
+	return FilesByPatternFS(OS, dir, pattern)
+}
+
+// FilesByPatternFS return files path matched by mattern in directory of fsys
+func FilesByPatternFS(fsys fs.FS, dir string, pattern string) ([]string, error) {
 	var matched []string
-	pattern = dir+"/"+pattern
-	err := filepath.Walk(dir+"/", func(path string, info os.FileInfo, err error) error {
+	pattern = path.Join(dir, pattern)
+	err := fs.WalkDir(fsys, dir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("cannot access path %q: %s\n", path, err.Error())
 		}
 
-		if info.IsDir() {
+		if entry.IsDir() {
 			return nil
 		}
 
@@ -155,7 +301,7 @@ func FilesByPattern(dir string, pattern string) ([]string, error) {
 		if pathIsMatched {
 			matched = append(matched, path)
 		}
-		
+
 		return nil
 	})
 	if err != nil {
@@ -166,9 +312,14 @@ func FilesByPattern(dir string, pattern string) ([]string, error) {
 }
 
 func UniteFilesByPaths(paths []string) (string, error) {
+	return UniteFilesByPathsFS(OS, paths)
+}
+
+// UniteFilesByPathsFS return files from fsys concatenated
+func UniteFilesByPathsFS(fsys fs.FS, paths []string) (string, error) {
 	outFile := strings.Builder{}
 	for _, path := range paths {
-		fileFromPath, err := ioutil.ReadFile(path)
+		fileFromPath, err := fs.ReadFile(fsys, path)
 		if err != nil {
 			return "", fmt.Errorf("cannot open file: \"%s\": %s", path, err.Error())
 		}
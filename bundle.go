@@ -0,0 +1,249 @@
+package gasx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// urlAttrRgxp href and src attributes of raw tag
+var urlAttrRgxp = regexp.MustCompile(`(?i)(\s(href|src)\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+
+// Bundle production bundle settings of Dist: content hashed file names, asset manifest and index.html inlining
+type Bundle struct {
+	// HashLength length of content hash in file names ("main.3f2a1c0b.wasm"), 8 if zero
+	HashLength int
+
+	// Manifest asset manifest file name, "manifest.json" if empty
+	Manifest string
+
+	// InlineExecScript put exec script into index.html instead of separate file
+	InlineExecScript bool
+
+	// CriticalStyles styles inlined in index.html head
+	CriticalStyles string
+}
+
+// Manifest hashed assets names by original names ("main.wasm": "main.3f2a1c0b.wasm")
+type Manifest map[string]string
+
+// hashedName return name with content hash before extension
+func (bundle *Bundle) hashedName(name string, data []byte) string {
+	length := bundle.HashLength
+	if length <= 0 {
+		length = 8
+	}
+
+	hash := hashString(string(data))
+	if length < len(hash) {
+		hash = hash[:length]
+	}
+
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+func (bundle *Bundle) manifestName() string {
+	if bundle.Manifest == "" {
+		return "manifest.json"
+	}
+
+	return bundle.Manifest
+}
+
+// readManifest return manifest of previous bundle, nil if there is no bundle
+func readManifest(fsys fs.FS, manifestPath string) Manifest {
+	body, err := fs.ReadFile(fsys, manifestPath)
+	if err != nil {
+		return nil
+	}
+
+	var manifest Manifest
+	json.Unmarshal(body, &manifest)
+	return manifest
+}
+
+// writeManifest write manifest and remove assets of previous builds which aren't used anymore:
+// not hashed files and hashed files of previous bundle
+func (bundle *Bundle) writeManifest(dist *Dist, manifest Manifest) error {
+	manifestPath := dist.Path(bundle.manifestName(), "")
+
+	var stale []string
+	for name := range manifest {
+		stale = append(stale, name)
+	}
+	for name, hashed := range readManifest(dist.fsys(), manifestPath) {
+		if hashed != "" && manifest[name] != hashed && assetName(hashed) == hashed {
+			stale = append(stale, hashed)
+		}
+	}
+
+	for _, name := range stale {
+		err := dist.fsys().Remove(dist.Path(name, ""))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error while removing old asset: %s", err.Error())
+		}
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = dist.fsys().WriteFile(manifestPath, append(body, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("error while writing manifest: %s", err.Error())
+	}
+
+	return nil
+}
+
+// rewriteIndex replace assets URLs in link and script tags by hashed names, inline exec script and critical styles.
+// Critical styles are added before the first stylesheet, script or body content, so they don't precede doctype and
+// charset. Markup which isn't changed is kept as is.
+func (bundle *Bundle) rewriteIndex(index string, manifest Manifest, execScriptName, execScript string) (string, error) {
+	var (
+		out          strings.Builder
+		tokenizer    = html.NewTokenizer(strings.NewReader(index))
+		inlineScript bool
+		inTitle      bool
+		critical     = bundle.CriticalStyles == ""
+	)
+	addCritical := func() {
+		if !critical {
+			out.WriteString("<style>\n" + bundle.CriticalStyles + "\n</style>\n")
+			critical = true
+		}
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if tokenizer.Err() != io.EOF {
+				return "", fmt.Errorf("error while parsing index.html: %s", tokenizer.Err().Error())
+			}
+			break
+		}
+
+		raw := string(tokenizer.Raw())
+		name, _ := tokenizer.TagName()
+		tag := string(name)
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch tag {
+			case "html", "head", "meta", "base":
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			default:
+				addCritical()
+			}
+
+			if tag != "link" && tag != "script" {
+				break
+			}
+
+			if tag == "script" && bundle.InlineExecScript && assetName(tagSrc(raw)) == execScriptName {
+				// "</script" in script would close the tag
+				out.WriteString("<script>\n" + strings.Replace(execScript, "</script", `<\/script`, -1) + "\n")
+
+				// content of script with src is replaced, self-closing script has no end tag
+				if tokenType == html.SelfClosingTagToken {
+					out.WriteString("</script>")
+				} else {
+					inlineScript = true
+				}
+				continue
+			}
+
+			raw = urlAttrRgxp.ReplaceAllStringFunc(raw, func(attr string) string {
+				match := urlAttrRgxp.FindStringSubmatch(attr)
+				value := strings.Trim(match[3], `"'`)
+
+				hashed, ok := manifest[assetName(value)]
+				if !ok {
+					return attr
+				}
+
+				return match[1] + strings.Replace(match[3], value, strings.TrimSuffix(value, assetName(value))+hashed, 1)
+			})
+		case html.EndTagToken:
+			switch tag {
+			case "script":
+				inlineScript = false
+			case "title":
+				inTitle = false
+			case "head", "body", "html":
+				addCritical()
+			}
+		case html.TextToken:
+			// inline script replaces src script content
+			if inlineScript {
+				continue
+			}
+
+			if !inTitle && strings.TrimSpace(raw) != "" {
+				addCritical()
+			}
+		}
+
+		out.WriteString(raw)
+	}
+
+	addCritical()
+	return out.String(), nil
+}
+
+// tagSrc return src attribute of raw tag
+func tagSrc(raw string) string {
+	for _, match := range urlAttrRgxp.FindAllStringSubmatch(raw, -1) {
+		if strings.EqualFold(match[2], "src") {
+			return strings.Trim(match[3], `"'`)
+		}
+	}
+
+	return ""
+}
+
+// assetName return file name of asset URL in dist root ("./main.css" -> "main.css"), empty string for other URLs
+func assetName(url string) string {
+	if strings.Contains(url, "://") || strings.HasPrefix(url, "//") {
+		return ""
+	}
+
+	url = strings.TrimPrefix(strings.TrimPrefix(url, "./"), "/")
+	if strings.Contains(url, "/") {
+		return ""
+	}
+
+	return url
+}
+
+// bundleFile write file to dist dir with content hashed name if bundle is set, returns written file name
+func (dist *Dist) bundleFile(manifest Manifest, name string, data []byte) (string, error) {
+	if dist.Bundle != nil {
+		hashed := dist.Bundle.hashedName(name, data)
+		manifest[name] = hashed
+		name = hashed
+	}
+
+	return name, dist.fsys().WriteFile(dist.Path(name, ""), data, 0644)
+}
+
+// bundleIndex return index.html for dist: source file as is or rewritten for bundle
+func (dist *Dist) bundleIndex(manifest Manifest, execScriptName, execScript string) ([]byte, error) {
+	index, err := fs.ReadFile(dist.fsys(), dist.Index)
+	if err != nil || dist.Bundle == nil {
+		return index, err
+	}
+
+	rewritten, err := dist.Bundle.rewriteIndex(string(index), manifest, execScriptName, execScript)
+	return []byte(rewritten), err
+}
@@ -0,0 +1,121 @@
+package gasx
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestRewriteIndex(t *testing.T) {
+	manifest := Manifest{"main.css": "main.1a2b.css", "wasm_exec.js": "wasm_exec.3c4d.js"}
+
+	tests := []struct {
+		name   string
+		bundle Bundle
+		index  string
+		want   string
+	}{
+		{
+			name:  "hashed names",
+			index: `<link rel="stylesheet" href="./main.css"><link href=main.css><script src='/wasm_exec.js'></script>`,
+			want:  `<link rel="stylesheet" href="./main.1a2b.css"><link href=main.1a2b.css><script src='/wasm_exec.3c4d.js'></script>`,
+		},
+		{
+			name:  "other urls are kept",
+			index: `<link href="https://cdn.example.com/main.css"><link href="static/main.css"><img src="main.css">`,
+			want:  `<link href="https://cdn.example.com/main.css"><link href="static/main.css"><img src="main.css">`,
+		},
+		{
+			name:   "inline script",
+			bundle: Bundle{InlineExecScript: true},
+			index:  `<body><script src="wasm_exec.js"></script><p>text</p></body>`,
+			want:   "<body><script>\nscript()<\\/script>\n</script><p>text</p></body>",
+		},
+		{
+			name:   "inline self-closing script",
+			bundle: Bundle{InlineExecScript: true},
+			index:  `<body><script src="wasm_exec.js"/><p>text</p></body>`,
+			want:   "<body><script>\nscript()<\\/script>\n</script><p>text</p></body>",
+		},
+		{
+			name:   "critical styles before stylesheet",
+			bundle: Bundle{CriticalStyles: "a{}"},
+			index:  "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>App</title><link href=\"main.css\"></head></html>",
+			want:   "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>App</title><style>\na{}\n</style>\n<link href=\"main.1a2b.css\"></head></html>",
+		},
+		{
+			name:   "critical styles without head end tag",
+			bundle: Bundle{CriticalStyles: "a{}"},
+			index:  "<!DOCTYPE html>\n<meta charset=\"utf-8\">\n<div id=\"app\"></div>",
+			want:   "<!DOCTYPE html>\n<meta charset=\"utf-8\">\n<style>\na{}\n</style>\n<div id=\"app\"></div>",
+		},
+		{
+			name:   "critical styles in empty document",
+			bundle: Bundle{CriticalStyles: "a{}"},
+			index:  "<!DOCTYPE html>",
+			want:   "<!DOCTYPE html><style>\na{}\n</style>\n",
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.bundle.rewriteIndex(test.index, manifest, "wasm_exec.js", "script()</script>")
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHashedName(t *testing.T) {
+	bundle := &Bundle{HashLength: 4}
+	if got := bundle.hashedName("main.wasm", []byte("")); got != "main.e3b0.wasm" {
+		t.Errorf("hashedName = %q", got)
+	}
+}
+
+func TestBundleWithoutWasmBuild(t *testing.T) {
+	fsys := NewMemFS(map[string]string{
+		"index.html":              `<script src="wasm_exec.js"></script>`,
+		"dist/main.00000000.wasm": "old binary",
+		"dist/main.css":           "stale",
+		"dist/manifest.json":      `{"main.wasm": "main.00000000.wasm", "main.css": "main.11111111.css"}`,
+		"dist/main.11111111.css":  "previous styles",
+	})
+
+	dist := &Dist{Dir: "dist", Index: "index.html", Styles: "a{}", Bundle: &Bundle{}, FS: fsys}
+	err := dist.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := fs.ReadFile(fsys, "dist/manifest.json")
+	var manifest Manifest
+	json.Unmarshal(body, &manifest)
+
+	wasmName := manifest["main.wasm"]
+	if wasm, err := fs.ReadFile(fsys, "dist/"+wasmName); err != nil || string(wasm) != "old binary" {
+		t.Errorf("previous wasm binary isn't bundled: %q %v", wasmName, err)
+	}
+
+	script, _ := fs.ReadFile(fsys, "dist/"+manifest["wasm_exec.js"])
+	if !strings.Contains(string(script), `"url":"`+wasmName+`"`) {
+		t.Errorf("loader doesn't fetch %s", wasmName)
+	}
+
+	for _, stale := range []string{"dist/main.css", "dist/main.11111111.css"} {
+		if ExistsFS(fsys, stale) {
+			t.Errorf("stale file %s isn't removed", stale)
+		}
+	}
+
+	dist = &Dist{Dir: "empty", Bundle: &Bundle{}, FS: NewMemFS(nil)}
+	if err := dist.Build(context.Background()); err == nil {
+		t.Errorf("expected error for bundle without wasm binary")
+	}
+}
@@ -0,0 +1,155 @@
+package gasx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const cacheManifestName = "gasx-cache.json"
+
+// buildCache compilation cache manifest
+type buildCache struct {
+	fsys WriteFS
+	path string
+	key  string
+
+	Files map[string]*cacheEntry `json:"files"`
+}
+
+type cacheEntry struct {
+	// Hash source and pipeline configuration hash
+	Hash string `json:"hash"`
+
+	// Output compiled file path
+	Output string `json:"output"`
+
+	// OutputHash compiled file hash
+	OutputHash string `json:"outputHash"`
+
+	// Artifacts compilers artifacts
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+}
+
+// loadCache read cache manifest from CacheDir. Returns nil if cache is disabled.
+func (builder *Builder) loadCache() (*buildCache, error) {
+	if builder.CacheDir == "" {
+		return nil, nil
+	}
+
+	key := Version + "\x00" + strconv.Itoa(len(builder.Middlewares)) + "\x00" + strconv.Itoa(len(builder.BlockCompilers))
+	for _, name := range builder.compilersNames() {
+		key += "\x00" + name
+	}
+	for _, cacheKey := range builder.CacheKeys {
+		key += "\x00" + cacheKey
+	}
+	for _, cacheKey := range builder.cacheKeys {
+		key += "\x00" + cacheKey()
+	}
+
+	// compiled files in overlay dir have absolute paths in line directives
+	if builder.OverlayDir != "" {
+		key += "\x00overlay"
+	}
+
+	cache := &buildCache{
+		fsys:  builder.outFS(),
+		path:  filepath.Join(builder.CacheDir, cacheManifestName),
+		key:   key,
+		Files: make(map[string]*cacheEntry),
+	}
+
+	manifest, err := fs.ReadFile(cache.fsys, cache.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+
+		return nil, fmt.Errorf("error while reading cache manifest: %s", err.Error())
+	}
+
+	err = json.Unmarshal(manifest, cache)
+	if err != nil || cache.Files == nil {
+		// broken manifest is the same as empty one
+		cache.Files = make(map[string]*cacheEntry)
+	}
+
+	return cache, nil
+}
+
+// AddCacheKey add function describing configuration of compiler, middleware or hook which changes compiled files.
+// It is called on every build, so configuration changed after registration invalidates cache too.
+// Change the key when compiler output changes (e.g. add version to it).
+func (builder *Builder) AddCacheKey(key func() string) {
+	builder.cacheKeys = append(builder.cacheKeys, key)
+}
+
+// hash return hash of file source with pipeline configuration
+func (cache *buildCache) hash(fileBody string) string {
+	if cache == nil {
+		return ""
+	}
+
+	return hashString(cache.key + "\x00" + fileBody)
+}
+
+// upToDate return compiled file and cached artifacts if compiled file exists and matches source hash
+func (cache *buildCache) upToDate(path, hash, output string) (string, map[string]string, bool) {
+	if cache == nil {
+		return "", nil, false
+	}
+
+	entry, ok := cache.Files[path]
+	if !ok || entry.Hash != hash || entry.Output != output {
+		return "", nil, false
+	}
+
+	outputBody, err := fs.ReadFile(cache.fsys, output)
+	if err != nil || hashString(string(outputBody)) != entry.OutputHash {
+		return "", nil, false
+	}
+
+	return string(outputBody), entry.Artifacts, true
+}
+
+func (cache *buildCache) set(path, hash, output, outputBody string, artifacts map[string]string) {
+	if cache == nil {
+		return
+	}
+
+	cache.Files[path] = &cacheEntry{
+		Hash:       hash,
+		Output:     output,
+		OutputHash: hashString(outputBody),
+		Artifacts:  artifacts,
+	}
+}
+
+func (cache *buildCache) save() error {
+	if cache == nil {
+		return nil
+	}
+
+	manifest, err := json.MarshalIndent(cache, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = cache.fsys.MkdirAll(filepath.Dir(cache.path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error while creating cache dir: %s", err.Error())
+	}
+
+	return cache.fsys.WriteFile(cache.path, manifest, 0644)
+}
+
+func hashString(a string) string {
+	sum := sha256.Sum256([]byte(a))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package gasx
+
+import "testing"
+
+func TestCacheKeys(t *testing.T) {
+	fsys := NewMemFS(map[string]string{
+		"app/a.gos": "package app\n\nvar A = 1\n",
+	})
+	files := []File{{Path: "app/a.gos", Extension: "gos"}}
+
+	var compiled int
+	config := "a"
+	builder := &Builder{
+		FS:       fsys,
+		CacheDir: ".cache",
+		AfterCompile: []FileHook{func(ctx *FileContext) error {
+			compiled++
+			return nil
+		}},
+	}
+	builder.AddCacheKey(func() string { return "middleware:" + config })
+
+	tests := []struct {
+		name     string
+		change   func()
+		compiled int
+	}{
+		{"first build", func() {}, 1},
+		{"cached build", func() {}, 0},
+		{"changed key func", func() { config = "b" }, 1},
+		{"changed cache keys", func() { builder.CacheKeys = []string{"x"} }, 1},
+		{"overlay", func() { builder.OverlayDir = ".overlay" }, 1},
+		{"cached overlay build", func() {}, 0},
+	}
+
+	for _, test := range tests {
+		compiled = 0
+		test.change()
+
+		err := builder.ParseFiles(files)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if compiled != test.compiled {
+			t.Errorf("%s: %d files compiled, want %d", test.name, compiled, test.compiled)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package gasx
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// StaleFile compiled file which differs from the one on disk
+type StaleFile struct {
+	File File
+
+	// Output compiled file path
+	Output string
+
+	// Diff unified diff from file on disk to actual compiled file
+	Diff string
+}
+
+// StaleFiles compiled files which are out of date
+type StaleFiles []StaleFile
+
+func (files StaleFiles) Error() string {
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Output)
+	}
+
+	return fmt.Sprintf("%d compiled file(s) are out of date: \n%s", len(files), strings.Join(paths, "\n"))
+}
+
+// CheckFiles compile GOS files in memory and compare them with compiled files on disk. Nothing is written,
+// BeforeFile and AfterCompile hooks are called.
+// Returns StaleFiles as error if some of compiled files are out of date (or BuildErrors if files can't be compiled).
+func (builder *Builder) CheckFiles(files []File) error {
+	results := make([]*fileResult, len(files))
+	builder.forEachFile(files, func(i int, fileInfo File) {
+		results[i] = builder.parseFile(nil, fileInfo)
+	})
+
+	var (
+		errs  BuildErrors
+		stale StaleFiles
+	)
+	for _, result := range results {
+		if result.err != nil {
+			errs = errs.add(result.err)
+			continue
+		}
+
+		oldName := result.Output
+		oldBody, err := fs.ReadFile(builder.outFS(), result.Output)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, &Diagnostic{File: result.Output, Severity: SeverityError, Message: "error while opening file: " + err.Error()})
+				continue
+			}
+
+			oldName = "/dev/null"
+		}
+
+		diff := unifiedDiff(oldName, result.Output, string(oldBody), result.Compiled)
+		if diff == "" {
+			continue
+		}
+
+		stale = append(stale, StaleFile{
+			File:   result.File,
+			Output: result.Output,
+			Diff:   diff,
+		})
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	if len(stale) != 0 {
+		return stale
+	}
+
+	return nil
+}
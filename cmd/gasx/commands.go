@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gascore/gasx"
+	"github.com/gascore/gasx/devserver"
+)
+
+// build compile GOS files, collect styles and build wasm
+func build(ctx context.Context, cfg *config) error {
+	return newProject(cfg).build(ctx)
+}
+
+// watch build application and rebuild it on changes of application directory.
+// Build output is served with live reload if development server address is set.
+func watch(ctx context.Context, cfg *config) error {
+	// development server and live reload need stable assets names
+	cfg.Bundle.Enabled = false
+
+	// watcher and server are stopped when watch returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := newProject(cfg)
+
+	var server *devserver.Server
+	if cfg.Watch.Serve != "" {
+		server = devserver.New(p.out(""))
+	}
+
+	var mu sync.Mutex
+	rebuild := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// build errors don't stop watching
+		err := gasx.LogError(p.build(ctx))
+		if server == nil {
+			return
+		}
+
+		if err != nil {
+			server.Error(err)
+			return
+		}
+
+		server.Reload()
+	}
+
+	rebuild()
+
+	serverErr := make(chan error, 1)
+	if server != nil {
+		gasx.Log("serving " + p.out("") + " on " + cfg.Watch.Serve)
+		go func() {
+			serverErr <- server.ListenAndServe(ctx, cfg.Watch.Serve)
+		}()
+	}
+
+	watchings := p.sources()
+	for _, watching := range cfg.Watch.Paths {
+		watchings = append(watchings, cfg.Path(watching))
+	}
+
+	watcherErr := make(chan error, 1)
+	go func() {
+		watcherErr <- gasx.StartWatcherContext(ctx, cfg.WatchInterval(), func(name string) {
+			gasx.Log("changed: " + name)
+			rebuild()
+		}, cfg.Watch.Ignore, nil, watchings)
+	}()
+
+	select {
+	case err := <-watcherErr:
+		return err
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// check compile GOS files in memory and print diffs of compiled files which are out of date
+func check(ctx context.Context, cfg *config) error {
+	p := newProject(cfg)
+
+	files, err := p.files()
+	if err != nil {
+		return err
+	}
+
+	err = p.builder.CheckFiles(files)
+	if stale, ok := err.(gasx.StaleFiles); ok {
+		for _, file := range stale {
+			fmt.Print(file.Diff)
+		}
+
+		return fmt.Errorf("%d compiled file(s) are out of date, run \"gasx build\"", len(stale))
+	}
+	if err != nil {
+		return err
+	}
+
+	gasx.Log(fmt.Sprintf("%d compiled file(s) are up to date", len(files)))
+	return nil
+}
+
+// clean remove compiled files, build artifacts and compilation cache
+func clean(ctx context.Context, cfg *config) error {
+	p := newProject(cfg)
+
+	var removed int
+	for _, source := range p.sources() {
+		err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() || !strings.HasSuffix(path, "_gas.go") {
+				return nil
+			}
+
+			_, _, ok, err := gasx.GeneratedSource(path)
+			if err != nil || !ok {
+				return err
+			}
+
+			removed++
+			return gasx.DeleteFileE(path)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error while removing compiled files: %s", err.Error())
+		}
+	}
+
+	for _, dir := range []string{cfg.Output.Dir, cfg.Output.Cache} {
+		if dir == "" {
+			continue
+		}
+
+		err := os.RemoveAll(cfg.Path(dir))
+		if err != nil {
+			return fmt.Errorf("error while removing %q: %s", dir, err.Error())
+		}
+	}
+
+	gasx.Log(fmt.Sprintf("removed %d compiled file(s)", removed))
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/gascore/gasx"
+)
+
+// config gasx command settings: project config with command line options
+type config struct {
+	*gasx.Config
+
+	// Build build wasm binary
+	Build bool
+}
+
+// flags command line flags, they override project config
+type flags struct {
+	set *flag.FlagSet
+
+	config     string
+	app        string
+	out        string
+	extensions listFlag
+	cache      string
+	workers    int
+	wasm       bool
+	compiler   string
+	bundle     bool
+	serve      string
+	quiet      bool
+	jsonLog    bool
+}
+
+func newFlags(name string) *flags {
+	defaults := gasx.DefaultConfig()
+
+	f := &flags{set: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.set.StringVar(&f.config, "config", "", "config file, gasx.json or gasx.toml in current directory by default")
+	f.set.StringVar(&f.app, "app", defaults.Sources[0], "application directory")
+	f.set.StringVar(&f.out, "out", defaults.Output.Dir, "build artifacts directory")
+	f.set.Var(&f.extensions, "ext", "comma-separated GOS files extensions (default \""+strings.Join(defaults.Extensions, ",")+"\")")
+	f.set.StringVar(&f.cache, "cache", defaults.Output.Cache, "compilation cache directory, empty to disable cache")
+	f.set.IntVar(&f.workers, "workers", defaults.Workers, "number of files compiled concurrently")
+	f.set.BoolVar(&f.wasm, "wasm", true, "build wasm binary")
+	f.set.StringVar(&f.compiler, "compiler", defaults.Wasm.Compiler, "wasm compiler, \"go\" or \"tinygo\"")
+	f.set.BoolVar(&f.bundle, "bundle", false, "build production bundle with hashed assets names")
+	f.set.StringVar(&f.serve, "serve", "", "development server address for watch command (e.g. \":8080\")")
+	f.set.BoolVar(&f.quiet, "quiet", false, "print only errors")
+	f.set.BoolVar(&f.jsonLog, "log-json", false, "print log as JSON lines")
+
+	return f
+}
+
+// load project config and override it by flags set in command line
+func (f *flags) load() (*config, error) {
+	configPath := f.config
+	if configPath == "" {
+		configPath = gasx.FindConfig(".")
+	}
+
+	cfg := &config{Config: gasx.DefaultConfig(), Build: f.wasm}
+	if configPath != "" {
+		projectConfig, err := gasx.LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Config = projectConfig
+	}
+
+	f.set.Visit(func(flag *flag.Flag) {
+		switch flag.Name {
+		case "app":
+			cfg.Sources = []string{f.app}
+			cfg.Main = ""
+		case "out":
+			cfg.Output.Dir = f.out
+		case "ext":
+			cfg.Extensions = f.extensions
+		case "cache":
+			cfg.Output.Cache = f.cache
+		case "workers":
+			cfg.Workers = f.workers
+		case "compiler":
+			cfg.Wasm.Compiler = f.compiler
+		case "bundle":
+			cfg.Bundle.Enabled = f.bundle
+		case "serve":
+			cfg.Watch.Serve = f.serve
+		}
+	})
+
+	// flags values aren't checked by LoadConfig
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (f *flags) setLogger() {
+	if f.jsonLog {
+		gasx.DefaultLogger = &gasx.JSONLogger{Level: gasx.LevelInfo, Quiet: f.quiet}
+		return
+	}
+
+	gasx.DefaultLogger = &gasx.TextLogger{Level: gasx.LevelInfo, Quiet: f.quiet}
+}
+
+// listFlag comma-separated list flag
+type listFlag []string
+
+func (list *listFlag) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *listFlag) Set(value string) error {
+	*list = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimPrefix(strings.TrimSpace(item), "."); item != "" {
+			*list = append(*list, item)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFlagsValidation(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// project without config file
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{"defaults", nil, false},
+		{"output dir", []string{"-out", "public"}, false},
+		{"output is current dir", []string{"-out", "."}, true},
+		{"output is parent dir", []string{"-out", ".."}, true},
+		{"output is source", []string{"-app", "web", "-out", "web"}, true},
+		{"cache is source", []string{"-cache", "app"}, true},
+		{"cache disabled", []string{"-cache", ""}, false},
+	}
+
+	for _, test := range tests {
+		flags := newFlags("gasx clean")
+		if err := flags.set.Parse(test.args); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		_, err := flags.load()
+		if (err != nil) != test.err {
+			t.Errorf("%s: error %v, want error %v", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Command gasx builds gas applications: compiles GOS files, collects styles and builds wasm.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/gascore/gasx"
+)
+
+const usage = `gasx - gas applications builder
+
+Usage:
+	gasx <command> [flags]
+
+Commands:
+	build    compile GOS files, collect styles and build wasm
+	watch    build application and rebuild it on changes
+	check    verify compiled files are up to date
+	clean    remove compiled files and build artifacts
+	version  print gasx version
+
+Run "gasx <command> -h" for command flags.
+`
+
+var commands = map[string]func(ctx context.Context, cfg *config) error{
+	"build": build,
+	"watch": watch,
+	"check": check,
+	"clean": clean,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run execute command from args, returns exit code
+func run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return 0
+	case "version":
+		fmt.Println(gasx.Version)
+		return 0
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	flags := newFlags("gasx " + args[0])
+	err := flags.set.Parse(args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+
+		return 2
+	}
+
+	flags.setLogger()
+
+	cfg, err := flags.load()
+	if err != nil {
+		gasx.LogError(err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = command(ctx, cfg)
+	if err != nil {
+		gasx.LogError(err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/gascore/gasx"
+	"github.com/gascore/gasx/acss"
+	"github.com/gascore/gasx/html"
+)
+
+// project gas application built by gasx command
+type project struct {
+	cfg     *config
+	builder *gasx.Builder
+	acss    *acss.Generator
+}
+
+func newProject(cfg *config) *project {
+	p := &project{
+		cfg: cfg,
+		builder: &gasx.Builder{
+			Workers: cfg.Workers,
+		},
+		acss: &acss.Generator{},
+	}
+	p.builder.StaleDirs = p.sources()
+	if cfg.Output.Cache != "" {
+		p.builder.CacheDir = cfg.Path(cfg.Output.Cache)
+	}
+	p.acss.Configure(cfg.ACSS)
+
+	compiler := html.NewCompiler()
+	compiler.AddOnElementInfo(p.acss.OnElementInfo())
+	compiler.Register(p.builder)
+	p.acss.Register(p.builder)
+
+	return p
+}
+
+// sources return source directories paths
+func (p *project) sources() []string {
+	var sources []string
+	for _, source := range p.cfg.Sources {
+		sources = append(sources, p.cfg.Path(source))
+	}
+
+	return sources
+}
+
+// out return path of file in output directory
+func (p *project) out(name string) string {
+	return filepath.Join(p.cfg.Path(p.cfg.Output.Dir), name)
+}
+
+// files return GOS files of application
+func (p *project) files() ([]gasx.File, error) {
+	var files []gasx.File
+	for _, source := range p.sources() {
+		sourceFiles, err := gasx.GasFilesCustomDir(source, p.cfg.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("error while searching GOS files: %s", err.Error())
+		}
+
+		files = append(files, sourceFiles...)
+	}
+
+	return files, nil
+}
+
+// build compile GOS files, collect styles and build wasm
+func (p *project) build(ctx context.Context) error {
+	start := time.Now()
+
+	// styles of previous failed build aren't collected
+	p.acss.Reset()
+
+	files, err := p.files()
+	if err != nil {
+		return err
+	}
+
+	err = p.builder.ParseFiles(files)
+	if err != nil {
+		return err
+	}
+
+	styles, err := p.styles()
+	if err != nil {
+		return err
+	}
+
+	bundle, err := p.bundle()
+	if err != nil {
+		return err
+	}
+
+	dist := &gasx.Dist{
+		Dir:        p.out(""),
+		WasmName:   p.cfg.Output.Wasm,
+		Index:      filepath.Join(p.cfg.MainDir(), "index.html"),
+		Styles:     styles,
+		StylesName: p.cfg.Output.Styles,
+		Loader:     p.cfg.Wasm.Loader.Options(),
+		Integrity:  p.cfg.Wasm.Loader.Integrity,
+		Bundle:     bundle,
+	}
+	if p.cfg.Build {
+		dist.Wasm = &gasx.WasmBuild{
+			Main:     p.cfg.MainDir(),
+			Compiler: p.cfg.Wasm.Compiler,
+			Tags:     p.cfg.Wasm.Tags,
+			LDFlags:  p.cfg.Wasm.LDFlags,
+			TrimPath: p.cfg.Wasm.TrimPath,
+			Log:      gasx.LogWriter(gasx.LevelInfo),
+		}
+	}
+
+	// TinyGo compatibility problems are reported before build, it can take a long time
+	var warnings []*gasx.Diagnostic
+	if p.cfg.Build && p.cfg.Wasm.Compiler == gasx.CompilerTinyGo {
+		warnings = p.builder.TinyGoDiagnostics(files)
+		for _, warning := range warnings {
+			gasx.LogLevel(gasx.LevelWarn, warning.Error())
+		}
+	}
+
+	err = dist.Build(ctx)
+	if err != nil {
+		if len(warnings) != 0 {
+			gasx.LogLevel(gasx.LevelWarn, fmt.Sprintf("build has probably failed because of %d TinyGo warning(s) above", len(warnings)))
+		}
+
+		return err
+	}
+
+	gasx.Log(fmt.Sprintf("built %d file(s) in %s", len(files), time.Since(start).Round(time.Millisecond)))
+	return nil
+}
+
+// bundle return production bundle settings, nil if bundle is disabled
+func (p *project) bundle() (*gasx.Bundle, error) {
+	if !p.cfg.Bundle.Enabled {
+		return nil, nil
+	}
+
+	var critical []string
+	for _, file := range p.cfg.Bundle.Critical {
+		critical = append(critical, p.cfg.Path(file))
+	}
+
+	criticalStyles, err := gasx.UniteFilesByPaths(critical)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading critical styles: %s", err.Error())
+	}
+
+	return &gasx.Bundle{
+		HashLength:       p.cfg.Bundle.Hash,
+		Manifest:         p.cfg.Bundle.Manifest,
+		InlineExecScript: p.cfg.Bundle.Inline,
+		CriticalStyles:   criticalStyles,
+	}, nil
+}
+
+// styles unite styles from styles.gas files of application and its deps, config styles patterns and atomic css
+func (p *project) styles() (string, error) {
+	var (
+		stylesFiles []string
+		already     = make(map[string]bool)
+	)
+	for _, source := range p.sources() {
+		sourceStyles, err := gasx.GrepStylesCustom(source, already)
+		if err != nil {
+			return "", fmt.Errorf("error while searching styles: %s", err.Error())
+		}
+		stylesFiles = append(stylesFiles, sourceStyles...)
+
+		for _, pattern := range p.cfg.Styles {
+			patternStyles, err := gasx.FilesByPattern(source, pattern)
+			if err != nil {
+				return "", fmt.Errorf("error while searching styles: %s", err.Error())
+			}
+			stylesFiles = append(stylesFiles, patternStyles...)
+		}
+	}
+
+	styles, err := gasx.UniteFilesByPaths(stylesFiles)
+	if err != nil {
+		return "", err
+	}
+
+	return styles + p.acss.GetStyles(), nil
+}
@@ -0,0 +1,681 @@
+package gasx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConfigNames config file names searched by FindConfig in order of priority
+var ConfigNames = []string{"gasx.json", "gasx.toml"}
+
+var extensionRgxp = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Config project configuration from gasx.json or gasx.toml
+type Config struct {
+	// Root project directory (config file directory), relative paths in config are relative to it
+	Root string `json:"-"`
+
+	// Sources directories with GOS files
+	Sources []string `json:"sources"`
+
+	// Main directory of application main package, the first source if empty
+	Main string `json:"main"`
+
+	// Extensions GOS files extensions
+	Extensions []string `json:"extensions"`
+
+	// Workers number of files compiled concurrently
+	Workers int `json:"workers"`
+
+	// Output build artifacts layout
+	Output OutputConfig `json:"output"`
+
+	// Wasm main package build settings
+	Wasm WasmConfig `json:"wasm"`
+
+	// ACSS atomic css generator settings
+	ACSS ACSSConfig `json:"acss"`
+
+	// Styles patterns of styles files in sources in addition to styles.gas ones
+	Styles []string `json:"styles"`
+
+	// Watch watcher settings
+	Watch WatchConfig `json:"watch"`
+
+	// Bundle production bundle settings
+	Bundle BundleConfig `json:"bundle"`
+}
+
+// OutputConfig build artifacts layout
+type OutputConfig struct {
+	// Dir directory for build artifacts
+	Dir string `json:"dir"`
+
+	// Cache compilation cache directory, cache is disabled if empty
+	Cache string `json:"cache"`
+
+	// Wasm wasm binary name in Dir
+	Wasm string `json:"wasm"`
+
+	// Styles styles file name in Dir
+	Styles string `json:"styles"`
+}
+
+// WasmConfig main package build settings
+type WasmConfig struct {
+	// Compiler "go" or "tinygo"
+	Compiler string `json:"compiler"`
+
+	// Tags build tags
+	Tags []string `json:"tags"`
+
+	// LDFlags linker flags
+	LDFlags string `json:"ldflags"`
+
+	// TrimPath remove file system paths from binary
+	TrimPath bool `json:"trimpath"`
+
+	// Loader wasm loader settings
+	Loader LoaderConfig `json:"loader"`
+}
+
+// LoaderConfig wasm loader settings (see LoaderOptions), URL is the output wasm name
+type LoaderConfig struct {
+	// Mount CSS selector of element for loading state and errors
+	Mount string `json:"mount"`
+
+	// Args program arguments
+	Args []string `json:"args"`
+
+	// Env program environment variables
+	Env map[string]string `json:"env"`
+
+	// Fallback instantiate wasm from ArrayBuffer if streaming isn't possible
+	Fallback bool `json:"fallback"`
+
+	// Progress global JS function called with loaded and total bytes
+	Progress string `json:"progress"`
+
+	// ShowErrors show loading errors on page
+	ShowErrors bool `json:"errors"`
+
+	// Integrity add wasm binary integrity hash
+	Integrity bool `json:"integrity"`
+}
+
+// Options return loader options
+func (loader LoaderConfig) Options() *LoaderOptions {
+	return &LoaderOptions{
+		Mount:      loader.Mount,
+		Args:       loader.Args,
+		Env:        loader.Env,
+		Fallback:   loader.Fallback,
+		Progress:   loader.Progress,
+		ShowErrors: loader.ShowErrors,
+	}
+}
+
+// ACSSConfig atomic css generator settings
+type ACSSConfig struct {
+	// Breakpoints media queries by breakpoint names
+	Breakpoints map[string]string `json:"breakpoints"`
+
+	// Tokens custom values by names
+	Tokens map[string]string `json:"tokens"`
+
+	// Exceptions classes which aren't generated
+	Exceptions []string `json:"exceptions"`
+}
+
+// WatchConfig watcher settings
+type WatchConfig struct {
+	// Paths directories watched in addition to sources
+	Paths []string `json:"paths"`
+
+	// Ignore suffixes of ignored files
+	Ignore []string `json:"ignore"`
+
+	// Interval files polling interval ("500ms", "3s")
+	Interval string `json:"interval"`
+
+	// Serve development server address (":8080"), server is disabled if empty
+	Serve string `json:"serve"`
+}
+
+// BundleConfig production bundle settings (see Bundle)
+type BundleConfig struct {
+	// Enabled build bundle with hashed assets names, watch command never builds bundle
+	Enabled bool `json:"enabled"`
+
+	// Hash length of content hash in file names
+	Hash int `json:"hash"`
+
+	// Manifest asset manifest file name in output directory
+	Manifest string `json:"manifest"`
+
+	// Inline put exec script into index.html
+	Inline bool `json:"inline"`
+
+	// Critical CSS files inlined into index.html head
+	Critical []string `json:"critical"`
+}
+
+// ConfigError invalid config value
+type ConfigError struct {
+	File string
+
+	// Key dotted path of key ("acss.breakpoints.md", "sources[1]")
+	Key string
+
+	// Line key line, 0 if it is unknown
+	Line int
+
+	Message    string
+	Suggestion string
+}
+
+func (err *ConfigError) Error() string {
+	out := err.File
+	if err.Line != 0 {
+		out += fmt.Sprintf(":%d", err.Line)
+	}
+	if err.Key != "" {
+		if out != "" {
+			out += ": "
+		}
+		out += err.Key
+	}
+	if out != "" {
+		out += ": "
+	}
+	out += err.Message
+
+	if err.Suggestion != "" {
+		out += "\n\t" + err.Suggestion
+	}
+
+	return out
+}
+
+// DefaultConfig return configuration of project without config file: "app" source, "dist" output
+func DefaultConfig() *Config {
+	return &Config{
+		Root:       ".",
+		Sources:    []string{"app"},
+		Extensions: []string{"gos", "gox"},
+		Workers:    runtime.NumCPU(),
+		Output: OutputConfig{
+			Dir:    "dist",
+			Cache:  ".gasx-cache",
+			Wasm:   "main.wasm",
+			Styles: "main.css",
+		},
+		Wasm: WasmConfig{
+			Compiler: CompilerGo,
+			Loader: LoaderConfig{
+				Fallback:   true,
+				ShowErrors: true,
+			},
+		},
+		Watch: WatchConfig{
+			Ignore:   []string{"_gas.go", "~"},
+			Interval: "3s",
+		},
+		Bundle: BundleConfig{
+			Hash:     8,
+			Manifest: "manifest.json",
+		},
+	}
+}
+
+// FindConfig return path of config file in dir, empty string if there is no config
+func FindConfig(dir string) string {
+	for _, name := range ConfigNames {
+		if configPath := filepath.Join(dir, name); Exists(configPath) {
+			return configPath
+		}
+	}
+
+	return ""
+}
+
+// LoadConfig read config file (gasx.json or gasx.toml). Missing keys have default values.
+// Returns *ConfigError (or BuildErrors of them) if config is invalid.
+func LoadConfig(configPath string) (*Config, error) {
+	return LoadConfigFS(OS, configPath)
+}
+
+// LoadConfigFS is LoadConfig for file in fsys
+func LoadConfigFS(fsys fs.FS, configPath string) (*Config, error) {
+	body, err := fs.ReadFile(fsys, configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading config: %s", err.Error())
+	}
+
+	var (
+		raw   map[string]interface{}
+		lines map[string]int
+	)
+	switch ext := path.Ext(configPath); ext {
+	case ".json":
+		raw, err = parseJSONConfig(configPath, body)
+		lines = jsonLines(body)
+	case ".toml":
+		raw, lines, err = parseTOML(string(body))
+		if tomlErr, ok := err.(*tomlError); ok {
+			err = &ConfigError{File: configPath, Line: tomlErr.Line, Message: tomlErr.Message}
+		}
+	default:
+		err = &ConfigError{File: configPath, Message: "unsupported config format " + ext, Suggestion: "use gasx.json or gasx.toml"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	cfg.Root = filepath.Dir(configPath)
+
+	decoder := &configDecoder{file: configPath, lines: lines}
+	decoder.decode("", raw, reflect.ValueOf(cfg).Elem())
+
+	// values which can't be decoded are reported once
+	invalid := make(map[string]bool)
+	for _, err := range decoder.errs {
+		invalid[err.(*ConfigError).Key] = true
+	}
+	for _, err := range cfg.validate(decoder.errorf) {
+		if !invalid[err.(*ConfigError).Key] {
+			decoder.errs = append(decoder.errs, err)
+		}
+	}
+
+	switch len(decoder.errs) {
+	case 0:
+		return cfg, nil
+	case 1:
+		return nil, decoder.errs[0]
+	default:
+		return nil, decoder.errs
+	}
+}
+
+func parseJSONConfig(configPath string, body []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var raw interface{}
+	err := decoder.Decode(&raw)
+	if err != nil {
+		configErr := &ConfigError{File: configPath, Message: "invalid json: " + err.Error()}
+		switch jsonErr := err.(type) {
+		case *json.SyntaxError:
+			configErr.Line, _ = position(string(body), int(jsonErr.Offset))
+		case *json.UnmarshalTypeError:
+			configErr.Key = jsonErr.Field
+			configErr.Line, _ = position(string(body), int(jsonErr.Offset))
+		}
+
+		return nil, configErr
+	}
+
+	table, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, &ConfigError{File: configPath, Message: "config must be an object"}
+	}
+
+	return table, nil
+}
+
+// jsonLines return lines of keys and array items of valid json object by dotted key paths
+func jsonLines(body []byte) map[string]int {
+	var (
+		lines   = make(map[string]int)
+		decoder = json.NewDecoder(bytes.NewReader(body))
+	)
+	line := func() int {
+		line, _ := position(string(body), int(decoder.InputOffset()))
+		return line
+	}
+
+	var walk func(key string) error
+	walk = func(key string) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := lines[key]; !ok && key != "" {
+			lines[key] = line()
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				name, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+
+				child := joinKey(key, fmt.Sprint(name))
+				lines[child] = line()
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", key, i)); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+
+		// closing delimiter
+		_, err = decoder.Token()
+		return err
+	}
+	walk("")
+
+	return lines
+}
+
+// validate check values, errorf create error for key
+func (cfg *Config) validate(errorf func(key, suggestion, format string, args ...interface{}) error) BuildErrors {
+	var errs BuildErrors
+	if len(cfg.Sources) == 0 {
+		errs = append(errs, errorf("sources", "add directory with GOS files, e.g. [\"app\"]", "at least one source directory is required"))
+	}
+	for i, source := range cfg.Sources {
+		if strings.TrimSpace(source) == "" {
+			errs = append(errs, errorf(fmt.Sprintf("sources[%d]", i), "", "source directory is empty"))
+		}
+	}
+
+	if len(cfg.Extensions) == 0 {
+		errs = append(errs, errorf("extensions", "add GOS files extensions, e.g. [\"gos\"]", "at least one extension is required"))
+	}
+	for i, ext := range cfg.Extensions {
+		cfg.Extensions[i] = strings.TrimPrefix(ext, ".")
+		if !extensionRgxp.MatchString(cfg.Extensions[i]) {
+			errs = append(errs, errorf(fmt.Sprintf("extensions[%d]", i), "use extension without dot, e.g. \"gos\"", "invalid extension %q", ext))
+		}
+	}
+
+	if cfg.Workers < 0 {
+		errs = append(errs, errorf("workers", "use 1 to compile files one by one", "workers number can't be negative"))
+	}
+
+	if cfg.Output.Dir == "" {
+		errs = append(errs, errorf("output.dir", "", "output directory is required"))
+	}
+	// output and cache directories are removed by "clean" command
+	for _, dir := range [][2]string{{"output.dir", cfg.Output.Dir}, {"output.cache", cfg.Output.Cache}} {
+		if dir[1] != "" && cfg.unsafeDir(dir[1]) {
+			errs = append(errs, errorf(dir[0], "use separate directory, e.g. \"dist\"", "directory %q contains project root or sources", dir[1]))
+		}
+	}
+	for _, file := range [][2]string{{"output.wasm", cfg.Output.Wasm}, {"output.styles", cfg.Output.Styles}} {
+		if file[1] == "" || strings.ContainsAny(file[1], "/\\") {
+			errs = append(errs, errorf(file[0], "", "invalid file name %q", file[1]))
+		}
+	}
+
+	if cfg.Bundle.Hash < 1 || cfg.Bundle.Hash > 64 {
+		errs = append(errs, errorf("bundle.hash", "use value from 1 to 64, e.g. 8", "invalid hash length %d", cfg.Bundle.Hash))
+	}
+	if strings.ContainsAny(cfg.Bundle.Manifest, "/\\") {
+		errs = append(errs, errorf("bundle.manifest", "", "invalid file name %q", cfg.Bundle.Manifest))
+	}
+
+	if cfg.Wasm.Compiler != CompilerGo && cfg.Wasm.Compiler != CompilerTinyGo {
+		errs = append(errs, errorf("wasm.compiler", fmt.Sprintf("use %q or %q", CompilerGo, CompilerTinyGo), "unknown compiler %q", cfg.Wasm.Compiler))
+	}
+
+	for _, name := range sortedConfigKeys(cfg.ACSS.Breakpoints) {
+		if strings.TrimSpace(cfg.ACSS.Breakpoints[name]) == "" {
+			errs = append(errs, errorf("acss.breakpoints."+name, "use media query, e.g. \"(min-width: 768px)\"", "breakpoint media query is empty"))
+		}
+	}
+
+	if cfg.Watch.Interval != "" {
+		interval, err := time.ParseDuration(cfg.Watch.Interval)
+		if err != nil || interval <= 0 {
+			errs = append(errs, errorf("watch.interval", "use duration, e.g. \"500ms\" or \"3s\"", "invalid interval %q", cfg.Watch.Interval))
+		}
+	}
+
+	return errs
+}
+
+// Validate check values changed after loading (e.g. by command line flags)
+func (cfg *Config) Validate() error {
+	errs := cfg.validate(func(key, suggestion, format string, args ...interface{}) error {
+		return &ConfigError{Key: key, Message: fmt.Sprintf(format, args...), Suggestion: suggestion}
+	})
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// unsafeDir return true if removing dir removes project root or source directory
+func (cfg *Config) unsafeDir(dir string) bool {
+	for _, name := range append([]string{"."}, cfg.Sources...) {
+		if containsPath(cfg.Path(dir), cfg.Path(name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsPath return true if dir is name or one of its parents
+func containsPath(dir, name string) bool {
+	rel, err := filepath.Rel(absPath(dir), absPath(name))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Path return path relative to config root
+func (cfg *Config) Path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(cfg.Root, name)
+}
+
+// MainDir return directory of application main package
+func (cfg *Config) MainDir() string {
+	if cfg.Main != "" {
+		return cfg.Path(cfg.Main)
+	}
+
+	if len(cfg.Sources) == 0 {
+		return cfg.Root
+	}
+
+	return cfg.Path(cfg.Sources[0])
+}
+
+// WatchInterval return watcher polling interval
+func (cfg *Config) WatchInterval() time.Duration {
+	interval, err := time.ParseDuration(cfg.Watch.Interval)
+	if err != nil || interval <= 0 {
+		return watchInterval
+	}
+
+	return interval
+}
+
+// configDecoder decoder of json or toml values to config struct
+type configDecoder struct {
+	file  string
+	lines map[string]int
+	errs  BuildErrors
+}
+
+func (decoder *configDecoder) errorf(key, suggestion, format string, args ...interface{}) error {
+	return &ConfigError{
+		File:       decoder.file,
+		Key:        key,
+		Line:       decoder.lines[key],
+		Message:    fmt.Sprintf(format, args...),
+		Suggestion: suggestion,
+	}
+}
+
+func (decoder *configDecoder) decode(key string, value interface{}, dst reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			decoder.typeError(key, "table", value)
+			return
+		}
+
+		fields := make(map[string]reflect.Value)
+		var names []string
+		for i := 0; i < dst.NumField(); i++ {
+			name := strings.Split(dst.Type().Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+
+			fields[name] = dst.Field(i)
+			names = append(names, name)
+		}
+
+		for _, name := range sortedConfigKeys(table) {
+			field, ok := fields[name]
+			if !ok {
+				var suggestion string
+				if closest := closestName(name, names); closest != "" {
+					suggestion = fmt.Sprintf("did you mean %q?", joinKey(key, closest))
+				}
+
+				decoder.errs = append(decoder.errs, decoder.errorf(joinKey(key, name), suggestion, "unknown key"))
+				continue
+			}
+
+			decoder.decode(joinKey(key, name), table[name], field)
+		}
+	case reflect.Map:
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			decoder.typeError(key, "table", value)
+			return
+		}
+
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+
+		for _, name := range sortedConfigKeys(table) {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			decoder.decode(joinKey(key, name), table[name], elem)
+			dst.SetMapIndex(reflect.ValueOf(name), elem)
+		}
+	case reflect.Slice:
+		array, ok := value.([]interface{})
+		if !ok {
+			decoder.typeError(key, "array", value)
+			return
+		}
+
+		slice := reflect.MakeSlice(dst.Type(), len(array), len(array))
+		for i, item := range array {
+			decoder.decode(fmt.Sprintf("%s[%d]", key, i), item, slice.Index(i))
+		}
+		dst.Set(slice)
+	case reflect.String:
+		str, ok := value.(string)
+		if !ok {
+			decoder.typeError(key, "string", value)
+			return
+		}
+
+		dst.SetString(str)
+	case reflect.Int:
+		var (
+			integer int64
+			err     error
+		)
+		switch number := value.(type) {
+		case int64:
+			integer = number
+		case json.Number:
+			integer, err = number.Int64()
+		default:
+			decoder.typeError(key, "integer", value)
+			return
+		}
+		if err != nil {
+			decoder.typeError(key, "integer", value)
+			return
+		}
+
+		dst.SetInt(integer)
+	case reflect.Bool:
+		boolean, ok := value.(bool)
+		if !ok {
+			decoder.typeError(key, "boolean", value)
+			return
+		}
+
+		dst.SetBool(boolean)
+	}
+}
+
+func (decoder *configDecoder) typeError(key, expected string, value interface{}) {
+	decoder.errs = append(decoder.errs, decoder.errorf(key, "", "expected %s, got %s", expected, configType(value)))
+}
+
+// configType return json/toml type name of value
+func configType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func sortedConfigKeys(m interface{}) []string {
+	var keys []string
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,158 @@
+package gasx
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		body   string
+		errors []string
+	}{
+		{
+			name: "valid json",
+			file: "project/gasx.json",
+			body: `{"sources": ["app"], "output": {"dir": "public"}}`,
+		},
+		{
+			name: "valid toml",
+			file: "project/gasx.toml",
+			body: "sources = [\"app\"]\n[output]\ndir = \"public\"\n",
+		},
+		{
+			name:   "json syntax error",
+			file:   "gasx.json",
+			body:   "{\n  \"sources\": [\"app\"],\n  \"workers\": 4,,\n}",
+			errors: []string{"gasx.json:3: invalid json: invalid character ',' looking for beginning of object key string"},
+		},
+		{
+			name:   "json type error",
+			file:   "gasx.json",
+			body:   "{\n  \"sources\": [\n    \"app\",\n    1\n  ],\n  \"workers\": \"4\"\n}",
+			errors: []string{"gasx.json:4: sources[1]: expected string, got number", "gasx.json:6: workers: expected integer, got string"},
+		},
+		{
+			name:   "json unknown key",
+			file:   "gasx.json",
+			body:   "{\n  \"sources\": [\"app\"],\n  \"output\": {\n    \"dirr\": \"dist\"\n  }\n}",
+			errors: []string{"gasx.json:4: output.dirr: unknown key\n\tdid you mean \"output.dir\"?"},
+		},
+		{
+			name: "decode and validation errors",
+			file: "gasx.toml",
+			body: "workers = -1\nwatch.interval = 3\n\n[output]\nwasm = \"dir/main.wasm\"\n",
+			errors: []string{
+				"gasx.toml:2: watch.interval: expected string, got integer",
+				"gasx.toml:1: workers: workers number can't be negative\n\tuse 1 to compile files one by one",
+				"gasx.toml:5: output.wasm: invalid file name \"dir/main.wasm\"",
+			},
+		},
+		{
+			name: "output is project root",
+			file: "project/gasx.toml",
+			body: "sources = [\"app\"]\n[output]\ndir = \".\"\n",
+			errors: []string{
+				"project/gasx.toml:3: output.dir: directory \".\" contains project root or sources\n\tuse separate directory, e.g. \"dist\"",
+			},
+		},
+		{
+			name: "output is parent of root",
+			file: "project/gasx.toml",
+			body: "sources = [\"app\"]\n[output]\ndir = \"..\"\n",
+			errors: []string{
+				"project/gasx.toml:3: output.dir: directory \"..\" contains project root or sources\n\tuse separate directory, e.g. \"dist\"",
+			},
+		},
+		{
+			name: "cache is source",
+			file: "project/gasx.toml",
+			body: "sources = [\"web/app\"]\n[output]\ndir = \"public\"\ncache = \"web/app\"\n",
+			errors: []string{
+				"project/gasx.toml:4: output.cache: directory \"web/app\" contains project root or sources\n\tuse separate directory, e.g. \"dist\"",
+			},
+		},
+		{
+			name: "output is parent of source",
+			file: "project/gasx.toml",
+			body: "sources = [\"web/app\"]\n[output]\ndir = \"web\"\n",
+			errors: []string{
+				"project/gasx.toml:3: output.dir: directory \"web\" contains project root or sources\n\tuse separate directory, e.g. \"dist\"",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cfg, err := LoadConfigFS(NewMemFS(map[string]string{test.file: test.body}), test.file)
+		if len(test.errors) == 0 {
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+				continue
+			}
+
+			if cfg.Root != filepath.Dir(test.file) || cfg.Output.Dir != "public" {
+				t.Errorf("%s: root %q, output dir %q", test.name, cfg.Root, cfg.Output.Dir)
+			}
+			continue
+		}
+
+		var got []string
+		for _, configErr := range flattenErrors(err) {
+			got = append(got, configErr.Error())
+		}
+
+		if len(got) != len(test.errors) {
+			t.Errorf("%s: errors:\n%q\nwant:\n%q", test.name, got, test.errors)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.errors[i] {
+				t.Errorf("%s: error %d:\ngot  %q\nwant %q", test.name, i, got[i], test.errors[i])
+			}
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		dir   string
+		cache string
+		err   string
+	}{
+		{"default", "dist", ".gasx-cache", ""},
+		{"name with source prefix", "app-dist", "", ""},
+		{"inside source", "app/dist", "", ""},
+		{"current dir", ".", "", "output.dir: directory \".\" contains project root or sources\n\tuse separate directory, e.g. \"dist\""},
+		{"cache is parent", "dist", "..", "output.cache: directory \"..\" contains project root or sources\n\tuse separate directory, e.g. \"dist\""},
+		{"source", "./app/", "", "output.dir: directory \"./app/\" contains project root or sources\n\tuse separate directory, e.g. \"dist\""},
+	}
+
+	for _, test := range tests {
+		cfg := DefaultConfig()
+		cfg.Output.Dir, cfg.Output.Cache = test.dir, test.cache
+
+		var got string
+		if err := cfg.Validate(); err != nil {
+			got = err.Error()
+		}
+
+		if got != test.err {
+			t.Errorf("%s: error %q, want %q", test.name, got, test.err)
+		}
+	}
+}
+
+// flattenErrors return errors of BuildErrors or err itself
+func flattenErrors(err error) []error {
+	if errs, ok := err.(BuildErrors); ok {
+		return errs
+	}
+	if err == nil {
+		return nil
+	}
+
+	return []error{err}
+}
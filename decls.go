@@ -0,0 +1,58 @@
+package gasx
+
+import "strings"
+
+// Import package import added by block compiler
+type Import struct {
+	// Name package name, empty for default one
+	Name string
+
+	// Path package import path
+	Path string
+}
+
+// AddImport add import to compiled file. Imports already existing in the file are skipped.
+func (info *BlockInfo) AddImport(name, path string) {
+	info.Imports = append(info.Imports, Import{Name: name, Path: path})
+}
+
+// AddDecl add top-level declaration (type, func, var, e.t.c.) to the end of compiled file.
+// The same declarations added by different blocks are added once.
+func (info *BlockInfo) AddDecl(decl string) {
+	info.Decls = append(info.Decls, strings.TrimSpace(decl))
+}
+
+// fileDecl declaration added by block compiler
+type fileDecl struct {
+	value     string
+	directive string
+}
+
+func (state *fileState) addImports(imports []Import) {
+	for _, imp := range imports {
+		if !state.hasImport(imp) {
+			state.imports = append(state.imports, imp)
+		}
+	}
+}
+
+func (state *fileState) hasImport(imp Import) bool {
+	for _, existing := range state.imports {
+		if existing == imp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addDecl add declaration with line directive pointing at the block which added it
+func (state *fileState) addDecl(decl, directive string) {
+	for _, existing := range state.decls {
+		if existing.value == decl {
+			return
+		}
+	}
+
+	state.decls = append(state.decls, fileDecl{value: decl, directive: directive})
+}
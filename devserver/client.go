@@ -0,0 +1,103 @@
+package devserver
+
+// ClientScript live reload client: page is reloaded on "reload" event and after server restart,
+// build errors are shown in overlay
+const ClientScript = `// gasx live reload
+(() => {
+	if (typeof EventSource === "undefined") {
+		return;
+	}
+
+	const overlayID = "gasx-error-overlay";
+
+	const el = (tag, style, text) => {
+		const node = document.createElement(tag);
+		node.setAttribute("style", style);
+		if (text) {
+			node.textContent = text;
+		}
+		return node;
+	};
+
+	const closeOverlay = () => {
+		const overlay = document.getElementById(overlayID);
+		if (overlay) {
+			overlay.remove();
+		}
+	};
+
+	const showOverlay = (errors) => {
+		closeOverlay();
+
+		const overlay = el("div", "position:fixed;top:0;left:0;right:0;bottom:0;z-index:2147483647;overflow:auto;padding:24px;" +
+			"background:rgba(24,24,24,.95);color:#e8e8e8;font:14px/1.5 monospace;");
+		overlay.id = overlayID;
+
+		const close = el("button", "float:right;background:none;border:1px solid #888;color:#e8e8e8;cursor:pointer;", "close");
+		close.onclick = closeOverlay;
+		overlay.appendChild(close);
+		overlay.appendChild(el("h2", "margin:0 0 16px;color:#ff6b6b;", "Build failed: " + errors.length + " error(s)"));
+
+		for (const error of errors) {
+			const item = el("div", "margin-bottom:24px;");
+
+			let position = error.file || "";
+			if (error.line) {
+				position += ":" + error.line + ":" + error.column;
+			}
+			if (error.block) {
+				position += " (in $" + error.block + " block)";
+			}
+			if (position) {
+				item.appendChild(el("div", "color:#8ab4f8;", position));
+			}
+
+			item.appendChild(el("div", "white-space:pre-wrap;color:#ff6b6b;", (error.severity || "error") + ": " + error.message));
+			if (error.suggestion) {
+				item.appendChild(el("div", "white-space:pre-wrap;color:#ffd866;", error.suggestion));
+			}
+
+			if (error.excerpt) {
+				const pre = el("pre", "margin:8px 0 0;padding:8px;background:#111;overflow:auto;");
+				const width = String(error.excerpt[error.excerpt.length - 1].line).length;
+				for (const line of error.excerpt) {
+					const current = line.line === error.line;
+					const text = (current ? "> " : "  ") + String(line.line).padStart(width) + " | " + line.text;
+					pre.appendChild(el("div", current ? "color:#fff;background:#4a1f1f;" : "color:#999;", text));
+
+					if (current && error.column) {
+						// tabs are kept, so caret is under the column
+						const caret = " ".repeat(width + 5) + line.text.slice(0, error.column - 1).replace(/[^\t]/g, " ") + "^";
+						pre.appendChild(el("div", "color:#ff6b6b;", caret));
+					}
+				}
+				item.appendChild(pre);
+			}
+
+			overlay.appendChild(item);
+		}
+
+		(document.body || document.documentElement).appendChild(overlay);
+	};
+
+	let serverID = null;
+	const events = new EventSource("` + EventsPath + `");
+
+	events.addEventListener("hello", (e) => {
+		const id = JSON.parse(e.data);
+		if (serverID !== null && serverID !== id) {
+			location.reload();
+		}
+		serverID = id;
+	});
+
+	events.addEventListener("reload", () => location.reload());
+
+	events.addEventListener("error", (e) => {
+		// "error" is also connection error event without data
+		if (e.data) {
+			showOverlay(JSON.parse(e.data));
+		}
+	});
+})();
+`
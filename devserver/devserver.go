@@ -0,0 +1,246 @@
+// Package devserver development server for gas applications: it serves build output and reloads pages after rebuilds.
+package devserver
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventsPath path of server-sent events stream
+	EventsPath = "/_gasx/events"
+
+	// ClientPath path of live reload client script
+	ClientPath = "/_gasx/client.js"
+
+	// keepAlive interval of comments keeping events stream open
+	keepAlive = 15 * time.Second
+
+	// fallbackPage page served while output doesn't have index (the first build failed)
+	fallbackPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>gasx</title><script src="` + ClientPath + `"></script></head>
+<body></body>
+</html>
+`
+)
+
+func init() {
+	// old systems mime databases don't know wasm, browsers require the type for WebAssembly.instantiateStreaming
+	mime.AddExtensionType(".wasm", "application/wasm")
+}
+
+// Server development server: serves Dir with index fallback for client-side routes and
+// pushes reload events to pages. Client script is added to wasm_exec.js served from Dir.
+type Server struct {
+	// Dir directory with build output
+	Dir string
+
+	// Index file served for paths without files (client-side routes), "index.html" if empty
+	Index string
+
+	// ExecScript name of wasm exec script which client script is added to, "wasm_exec.js" if empty
+	ExecScript string
+
+	// id server instance id, pages are reloaded after server restart
+	id string
+
+	mu      sync.Mutex
+	clients map[chan event]bool
+
+	// lastError errors of the last build as JSON, empty after successful build
+	lastError string
+}
+
+type event struct {
+	name string
+	data string
+}
+
+// New create server for directory
+func New(dir string) *Server {
+	return &Server{
+		Dir: dir,
+		id:  strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// Reload reload all connected pages (and close errors overlay)
+func (s *Server) Reload() {
+	s.mu.Lock()
+	s.lastError = ""
+	s.mu.Unlock()
+
+	s.sendRaw(event{name: "reload", data: "{}"})
+}
+
+// sendRaw send event to all connected pages
+func (s *Server) sendRaw(e event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client <- e:
+		default: // slow client misses event
+		}
+	}
+}
+
+// ListenAndServe serve HTTP on addr until ctx is done
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: s}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		// events streams are never finished, so connections are closed without graceful shutdown
+		server.Close()
+		return nil
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	switch r.URL.Path {
+	case EventsPath:
+		s.serveEvents(w, r)
+		return
+	case ClientPath:
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		fmt.Fprint(w, ClientScript)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	file := filepath.Join(s.Dir, filepath.FromSlash(name))
+
+	info, err := os.Stat(file)
+	if err == nil && info.IsDir() {
+		file = filepath.Join(file, s.index())
+		_, err = os.Stat(file)
+	}
+
+	if err != nil {
+		// client-side routes don't have extensions, missing assets are errors
+		if path.Ext(name) != "" && path.Base(name) != s.execScript() {
+			http.NotFound(w, r)
+			return
+		}
+
+		if path.Ext(name) == "" {
+			file = filepath.Join(s.Dir, s.index())
+		}
+	}
+
+	if _, err := os.Stat(file); err != nil && filepath.Base(file) == s.index() {
+		// the first build failed, page with client shows errors and is reloaded after successful build
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, fallbackPage)
+		return
+	}
+
+	if filepath.Base(file) == s.execScript() {
+		s.serveExecScript(w, r, file)
+		return
+	}
+
+	http.ServeFile(w, r, file)
+}
+
+// serveExecScript serve wasm exec script with live reload client, only client is served if script isn't built yet
+func (s *Server) serveExecScript(w http.ResponseWriter, r *http.Request, file string) {
+	script, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Write(script)
+	fmt.Fprint(w, "\n"+ClientScript)
+}
+
+// serveEvents stream events to page until it is closed
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Connection", "keep-alive")
+
+	client := make(chan event, 8)
+	s.mu.Lock()
+	if s.clients == nil {
+		s.clients = make(map[chan event]bool)
+	}
+	s.clients[client] = true
+	lastError := s.lastError
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	writeEvent(w, event{name: "hello", data: strconv.Quote(s.id)})
+	if lastError != "" {
+		writeEvent(w, event{name: "error", data: lastError})
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-client:
+			writeEvent(w, e)
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, e event) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.name, strings.Replace(e.data, "\n", "\ndata: ", -1))
+}
+
+func (s *Server) index() string {
+	if s.Index == "" {
+		return "index.html"
+	}
+
+	return s.Index
+}
+
+func (s *Server) execScript() string {
+	if s.ExecScript == "" {
+		return "wasm_exec.js"
+	}
+
+	return s.ExecScript
+}
@@ -0,0 +1,189 @@
+package devserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gascore/gasx"
+)
+
+// eventStream connected events client
+type eventStream struct {
+	resp   *http.Response
+	reader *bufio.Reader
+}
+
+func connect(t *testing.T, url string) *eventStream {
+	resp, err := http.Get(url + EventsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &eventStream{resp: resp, reader: bufio.NewReader(resp.Body)}
+}
+
+// next return name and data of next event
+func (stream *eventStream) next(t *testing.T) (string, string) {
+	var name string
+	var data []string
+	for {
+		line, err := stream.reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, strings.Join(data, "\n")
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func (stream *eventStream) close() {
+	stream.resp.Body.Close()
+}
+
+// waitClients wait until server has n connected clients
+func waitClients(t *testing.T, server *Server, n int) {
+	for i := 0; i < 100; i++ {
+		server.mu.Lock()
+		clients := len(server.clients)
+		server.mu.Unlock()
+
+		if clients == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("%d clients aren't connected", n)
+}
+
+func TestEvents(t *testing.T) {
+	server := New(t.TempDir())
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	buildErr := &gasx.Diagnostic{File: "app/a.gos", Line: 3, Column: 2, Severity: gasx.SeverityError, Message: "invalid html"}
+
+	tests := []struct {
+		name string
+
+		// build result before client is connected (first build)
+		before error
+
+		// build result after client is connected, nil for successful build
+		after error
+
+		events []string
+	}{
+		{"first build failed", buildErr, nil, []string{"hello", "error", "reload"}},
+		{"first build succeeded", nil, buildErr, []string{"hello", "error"}},
+		{"rebuild", nil, nil, []string{"hello", "reload"}},
+		{"not diagnostic error", errors.New("wasm build failed"), nil, []string{"hello", "error", "reload"}},
+	}
+
+	for _, test := range tests {
+		if test.before != nil {
+			server.Error(test.before)
+		} else {
+			server.Reload()
+		}
+
+		stream := connect(t, httpServer.URL)
+		waitClients(t, server, 1)
+
+		if test.after != nil {
+			server.Error(test.after)
+		} else {
+			server.Reload()
+		}
+
+		for i, want := range test.events {
+			name, data := stream.next(t)
+			if name != want {
+				t.Errorf("%s: event %d %q, want %q", test.name, i, name, want)
+				break
+			}
+
+			if name == "error" && !strings.Contains(data, `"severity":"error"`) {
+				t.Errorf("%s: error event data %s", test.name, data)
+			}
+		}
+
+		stream.close()
+		waitClients(t, server, 0)
+	}
+}
+
+func TestFirstBuildFailed(t *testing.T) {
+	dir := t.TempDir()
+	server := New(dir)
+	server.Error(errors.New("build failed"))
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		contains string
+	}{
+		{"index", "/", http.StatusOK, ClientPath},
+		{"client route", "/users/1", http.StatusOK, ClientPath},
+		{"exec script", "/wasm_exec.js", http.StatusOK, "gasx live reload"},
+		{"missing asset", "/main.wasm", http.StatusNotFound, ""},
+	}
+
+	check := func(stage string) {
+		for _, test := range tests {
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if recorder.Code != test.status || !strings.Contains(recorder.Body.String(), test.contains) {
+				t.Errorf("%s %s: status %d, body:\n%s", stage, test.name, recorder.Code, recorder.Body.String())
+			}
+		}
+	}
+	check("failed build")
+
+	// the next build writes output, pages are reloaded to it
+	files := map[string]string{"index.html": "<html>" + ClientPath + "</html>", "wasm_exec.js": "// exec", "main.wasm": "\x00asm"}
+	for name, body := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(body), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests[3].status = http.StatusOK
+	check("successful build")
+}
+
+func TestListenAndServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(t.TempDir()).ListenAndServe(ctx, "127.0.0.1:0")
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server isn't stopped after cancel")
+	}
+}
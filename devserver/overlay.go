@@ -0,0 +1,77 @@
+package devserver
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/gascore/gasx"
+)
+
+// excerptContext number of lines shown before and after diagnostic line
+const excerptContext = 2
+
+// overlayError error shown in browser overlay
+type overlayError struct {
+	*gasx.Diagnostic
+
+	// Excerpt source lines around diagnostic position
+	Excerpt []excerptLine `json:"excerpt,omitempty"`
+}
+
+type excerptLine struct {
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Error show build error in overlay of connected pages (and pages connected later).
+// Overlay is closed by the next Reload.
+func (s *Server) Error(err error) {
+	if err == nil {
+		return
+	}
+
+	var errs []overlayError
+	for _, diagnostic := range gasx.Diagnostics(err) {
+		errs = append(errs, overlayError{
+			Diagnostic: diagnostic,
+			Excerpt:    excerpt(diagnostic),
+		})
+	}
+
+	body, jsonErr := json.Marshal(errs)
+	if jsonErr != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.lastError = string(body)
+	s.mu.Unlock()
+
+	s.sendRaw(event{name: "error", data: string(body)})
+}
+
+// excerpt return source lines around diagnostic line
+func excerpt(diagnostic *gasx.Diagnostic) []excerptLine {
+	if diagnostic.File == "" || diagnostic.Line == 0 {
+		return nil
+	}
+
+	src, err := os.ReadFile(diagnostic.File)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(string(src), "\n")
+
+	var out []excerptLine
+	for line := diagnostic.Line - excerptContext; line <= diagnostic.Line+excerptContext; line++ {
+		if line < 1 || line > len(lines) {
+			continue
+		}
+
+		out = append(out, excerptLine{Line: line, Text: strings.TrimRight(lines[line-1], "\r")})
+	}
+
+	return out
+}
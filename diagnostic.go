@@ -0,0 +1,97 @@
+package gasx
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Severity diagnostic severity
+type Severity string
+
+const (
+	// SeverityError compilation can't be finished
+	SeverityError Severity = "error"
+
+	// SeverityWarning compilation is finished, but something may be wrong
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic compilation problem with position in GOS file.
+// BlockCompiler can return it as error, Offset will be converted to Line and Column by Builder.
+type Diagnostic struct {
+	// File GOS file path
+	File string `json:"file"`
+
+	// Line, Column 1-based position in file, zero if unknown
+	Line   int `json:"line"`
+	Column int `json:"column"`
+
+	// Block special block name
+	Block string `json:"block,omitempty"`
+
+	Severity Severity `json:"severity"`
+
+	Message string `json:"message"`
+
+	// Suggestion optional hint how to fix the problem
+	Suggestion string `json:"suggestion,omitempty"`
+
+	// Offset byte offset in BlockInfo.Value
+	Offset int `json:"-"`
+}
+
+// Error return diagnostic as human readable text
+func (d *Diagnostic) Error() string {
+	var out string
+	switch {
+	case d.File == "":
+	case d.Line == 0:
+		out = d.File + ": "
+	default:
+		out = fmt.Sprintf("%s:%d:%d: ", d.File, d.Line, d.Column)
+	}
+
+	severity := d.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+	out += string(severity) + ": " + d.Message
+
+	if d.Block != "" {
+		out += " (in $" + d.Block + " block)"
+	}
+
+	if d.Suggestion != "" {
+		out += "\n\t" + d.Suggestion
+	}
+
+	return out
+}
+
+// Diagnostics convert error returned by Builder to diagnostics list
+func Diagnostics(err error) []*Diagnostic {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case *Diagnostic:
+		return []*Diagnostic{err}
+	case BuildErrors:
+		var out []*Diagnostic
+		for _, fileErr := range err {
+			out = append(out, Diagnostics(fileErr)...)
+		}
+		return out
+	default:
+		return []*Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+	}
+}
+
+// DiagnosticsJSON return error returned by Builder as JSON array of diagnostics (for editors)
+func DiagnosticsJSON(err error) ([]byte, error) {
+	diagnostics := Diagnostics(err)
+	if diagnostics == nil {
+		diagnostics = []*Diagnostic{}
+	}
+
+	return json.Marshal(diagnostics)
+}
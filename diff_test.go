@@ -0,0 +1,55 @@
+package gasx
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "equal", a: "a\nb\n", b: "a\nb\n", want: ""},
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n",
+			b:    "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nM\nn\nx",
+			want: "--- a\n+++ b\n@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n@@ -10,5 +10,6 @@\n j\n k\n l\n-m\n+M\n n\n+x\n\\ No newline at end of file\n",
+		},
+		{
+			name: "merged hunks",
+			a:    "a\nb\nc\nd\ne\nf\ng\nh\n",
+			b:    "A\nb\nc\nd\ne\nf\ng\nH\n",
+			want: "--- a\n+++ b\n@@ -1,8 +1,8 @@\n-a\n+A\n b\n c\n d\n e\n f\n g\n-h\n+H\n",
+		},
+		{
+			name: "added to empty",
+			a:    "",
+			b:    "x\n",
+			want: "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+x\n",
+		},
+		{
+			name: "removed all",
+			a:    "x\n",
+			b:    "",
+			want: "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-x\n",
+		},
+		{
+			name: "inserted lines",
+			a:    "a\nb\n",
+			b:    "a\nx\ny\nb\n",
+			want: "--- a\n+++ b\n@@ -1,2 +1,4 @@\n a\n+x\n+y\n b\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := unifiedDiff("a", "b", test.a, test.b); got != test.want {
+			t.Errorf("%s:\ngot\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
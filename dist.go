@@ -0,0 +1,211 @@
+package gasx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dist build output directory: wasm binary, exec script, index.html and styles
+type Dist struct {
+	// Dir output directory
+	Dir string
+
+	// Wasm main package build, Output is set to WasmName in Dir. Wasm isn't built if nil.
+	Wasm *WasmBuild
+
+	// WasmName wasm binary name, "main.wasm" if empty
+	WasmName string
+
+	// ExecScriptName exec script name, "wasm_exec.js" if empty
+	ExecScriptName string
+
+	// Index index.html path copied to Dir (assets URLs are rewritten in bundle), skipped if empty or doesn't exist
+	Index string
+
+	// Styles collected styles
+	Styles string
+
+	// StylesName styles file name, "main.css" if empty
+	StylesName string
+
+	// Loader wasm loader settings, DefaultLoaderOptions if nil. Empty URL is set to WasmName.
+	Loader *LoaderOptions
+
+	// Integrity add wasm binary integrity hash to loader
+	Integrity bool
+
+	// Bundle production bundle settings, files names aren't hashed if nil
+	Bundle *Bundle
+
+	// FS file system of Dir and Index, OS if nil. Wasm is built by compiler, so it needs OS.
+	FS WriteFS
+}
+
+// Build create output directory with build artifacts
+func (dist *Dist) Build(ctx context.Context) error {
+	fsys := dist.fsys()
+
+	err := fsys.MkdirAll(dist.Dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error while creating dist dir: %s", err.Error())
+	}
+
+	manifest := make(Manifest)
+	_, err = dist.bundleFile(manifest, defaultName(dist.StylesName, "main.css"), []byte(dist.Styles))
+	if err != nil {
+		return fmt.Errorf("error while writing styles: %s", err.Error())
+	}
+
+	loader := DefaultLoaderOptions()
+	if dist.Loader != nil {
+		loader = *dist.Loader
+	}
+	if loader.URL == "" {
+		loader.URL = filepath.ToSlash(defaultName(dist.WasmName, "main.wasm"))
+	}
+
+	var (
+		dir, compiler = ".", CompilerGo
+		wasmName      = defaultName(dist.WasmName, "main.wasm")
+		wasmPath      = dist.Path(wasmName, "")
+		wasm          []byte
+	)
+	if dist.Wasm != nil {
+		dist.Wasm.Output = wasmPath
+		err = dist.Wasm.Build(ctx)
+		if err != nil {
+			return err
+		}
+		dir, compiler = dist.Wasm.Main, dist.Wasm.Compiler
+
+		wasm, err = fs.ReadFile(fsys, wasmPath)
+		if err != nil {
+			return fmt.Errorf("error while reading wasm binary: %s", err.Error())
+		}
+	} else if dist.Bundle != nil {
+		// binary of previous build is bundled, loader must point to existing file
+		wasmPath, wasm, err = dist.previousWasm(wasmName)
+		if err != nil {
+			return err
+		}
+	}
+
+	if wasm != nil && dist.Integrity {
+		loader.Integrity = IntegrityHash(wasm)
+	}
+
+	// binary is moved to hashed name, so it isn't written twice
+	if dist.Bundle != nil {
+		manifest[wasmName] = dist.Bundle.hashedName(wasmName, wasm)
+		loader.URL = manifest[wasmName]
+
+		err = dist.moveFile(wasmPath, dist.Path(manifest[wasmName], ""), wasm)
+		if err != nil {
+			return fmt.Errorf("error while renaming wasm binary: %s", err.Error())
+		}
+	}
+
+	// exec script must be from the same Go release as compiler
+	script, err := GetCompilerExecScript(ctx, compiler, dir, loader)
+	if err != nil {
+		return fmt.Errorf("error while resolving exec script: %s", err.Error())
+	}
+
+	if dist.Wasm != nil {
+		err = CheckExecScript(wasm, script)
+		if err != nil {
+			return err
+		}
+	}
+
+	var (
+		hasIndex       = dist.Index != "" && ExistsFS(fsys, dist.Index)
+		execScriptName = defaultName(dist.ExecScriptName, "wasm_exec.js")
+	)
+	if hasIndex && dist.Bundle != nil && dist.Bundle.InlineExecScript {
+		// script is inlined in index.html
+		err = fsys.Remove(dist.Path(execScriptName, ""))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error while removing exec script: %s", err.Error())
+		}
+	} else {
+		_, err = dist.bundleFile(manifest, execScriptName, []byte(script))
+		if err != nil {
+			return fmt.Errorf("error while writing exec script: %s", err.Error())
+		}
+	}
+
+	if hasIndex {
+		index, err := dist.bundleIndex(manifest, execScriptName, script)
+		if err != nil {
+			return err
+		}
+
+		err = fsys.WriteFile(dist.Path("index.html", ""), index, 0644)
+		if err != nil {
+			return fmt.Errorf("error while writing index.html: %s", err.Error())
+		}
+	}
+
+	if dist.Bundle == nil {
+		return nil
+	}
+
+	return dist.Bundle.writeManifest(dist, manifest)
+}
+
+func (dist *Dist) fsys() WriteFS {
+	if dist.FS == nil {
+		return OS
+	}
+
+	return dist.FS
+}
+
+// previousWasm return path and body of wasm binary of previous build: bundled or not bundled one
+func (dist *Dist) previousWasm(wasmName string) (string, []byte, error) {
+	candidates := []string{dist.Path(wasmName, "")}
+	if hashed := readManifest(dist.fsys(), dist.Path(dist.Bundle.manifestName(), ""))[wasmName]; assetName(hashed) == hashed && hashed != "" {
+		candidates = append([]string{dist.Path(hashed, "")}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		wasm, err := fs.ReadFile(dist.fsys(), candidate)
+		if err == nil {
+			return candidate, wasm, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("bundle without wasm build needs wasm binary of previous build, %s isn't found in %s", wasmName, dist.Dir)
+}
+
+// moveFile move file with body data, nothing is done if paths are the same
+func (dist *Dist) moveFile(from, to string, data []byte) error {
+	if from == to {
+		return nil
+	}
+
+	err := dist.fsys().WriteFile(to, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return dist.fsys().Remove(from)
+}
+
+// Path return path of file in Dir, def is used if name is empty
+func (dist *Dist) Path(name, def string) string {
+	return filepath.Join(dist.Dir, defaultName(name, def))
+}
+
+func defaultName(name, def string) string {
+	if name == "" {
+		return def
+	}
+
+	return name
+}
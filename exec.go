@@ -1,16 +1,34 @@
 package gasx
 
-// GetWASMExecScript return wasm execution script
+import "context"
+
+// GetWASMExecScript return embedded Go 1.13 wasm execution script with default loader.
+// Use GetWASMExecScriptE to get script matching Go version used for build.
 func GetWASMExecScript() string {
-	return execScript + wasmLoader
+	return execScript + WASMLoader(DefaultLoaderOptions())
+}
+
+// GetWASMExecScriptE return wasm execution script matching Go version used for build in dir followed by loader
+func GetWASMExecScriptE(ctx context.Context, dir string, loader LoaderOptions) (string, error) {
+	return GetCompilerExecScript(ctx, CompilerGo, dir, loader)
 }
 
-const wasmLoader = `
-const go = new Go();
-WebAssembly.instantiateStreaming(fetch("main.wasm"), go.importObject).then((result) => {
-	go.run(result.instance);
-});`
+// GetCompilerExecScript return wasm execution script of compiler (CompilerGo or CompilerTinyGo) used in dir followed by loader
+func GetCompilerExecScript(ctx context.Context, compiler, dir string, loader LoaderOptions) (string, error) {
+	toolchain, err := LookupToolchain(ctx, compiler, dir)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := toolchain.ExecScript()
+	if err != nil {
+		return "", err
+	}
+
+	return script + WASMLoader(loader), nil
+}
 
+// execScript wasm_exec.js from Go 1.13 ("go" import module)
 const execScript = `// Copyright 2018 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
@@ -1,8 +1,8 @@
 package gasx
 
 import (
+	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -24,8 +24,13 @@ func GasFiles(extensions []string) ([]File, error) {
 
 // GasFilesCustomDir find files for builder in directory
 func GasFilesCustomDir(root string, extensions []string) ([]File, error) {
+	return GasFilesFS(OS, root, extensions)
+}
+
+// GasFilesFS find files for builder in directory of fsys
+func GasFilesFS(fsys fs.FS, root string, extensions []string) ([]File, error) {
 	var files []File
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	err := fs.WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
 		for _, ext := range extensions {
 			if strings.HasSuffix(path, "."+ext) {
 				files = append(files, File{Path: path, Extension: ext})
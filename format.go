@@ -0,0 +1,309 @@
+package gasx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// outputBlock compiled top-level block in compiled file
+type outputBlock struct {
+	span
+	block *blockNode
+}
+
+// formatFile validate compiled file, add imports and declarations from compilers and format it by gofmt
+func formatFile(fileInfo File, state *fileState, src, compiled string, blocks []outputBlock) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, outputPath(fileInfo), compiled, parser.ParseComments)
+	if err != nil {
+		return "", syntaxDiagnostic(fileInfo, src, compiled, blocks, err)
+	}
+
+	var expected []token.Position
+	for _, pos := range declPositions(file) {
+		expected = append(expected, fset.Position(pos))
+	}
+
+	compiled, added := addDecls(fset, file, compiled, state)
+	if len(state.decls) != 0 {
+		_, err = parser.ParseFile(token.NewFileSet(), outputPath(fileInfo), compiled, parser.ParseComments)
+		if err != nil {
+			return "", &Diagnostic{File: fileInfo.Path, Severity: SeverityError, Message: "invalid declaration added by compiler: " + err.Error()}
+		}
+	}
+
+	formatted, err := format.Source([]byte(compiled))
+	if err != nil {
+		return "", &Diagnostic{File: fileInfo.Path, Severity: SeverityError, Message: "error while formatting compiled file: " + err.Error()}
+	}
+
+	resynced, moved := resyncDecls(fileInfo, state.sourceName, generatedHeader(state.sourceName, src)+string(formatted), expected, added)
+	if !moved {
+		return resynced, nil
+	}
+
+	// gofmt puts directives after doc comments text
+	formatted, err = format.Source([]byte(resynced))
+	if err != nil {
+		return "", &Diagnostic{File: fileInfo.Path, Severity: SeverityError, Message: "error while formatting compiled file: " + err.Error()}
+	}
+
+	return string(formatted), nil
+}
+
+// resyncDecls add line directives before package clause and declarations moved by header and gofmt (it removes extra empty lines).
+// Imports added by compilers are skipped. Returns false if there is no moved declarations.
+func resyncDecls(fileInfo File, sourceName, formatted string, expected []token.Position, added span) (string, bool) {
+	var moved bool
+	for range expected {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, outputPath(fileInfo), formatted, parser.ParseComments)
+		if err != nil {
+			break
+		}
+
+		positions := declPositions(file)
+		positions = append(positions[:added.start:added.start], positions[added.end:]...)
+
+		i := movedDecl(fset, positions, expected)
+		if i == -1 {
+			break
+		}
+		moved = true
+
+		offset := fset.Position(positions[i]).Offset
+		offset = strings.LastIndex(formatted[:offset], "\n") + 1
+
+		directive := fmt.Sprintf("//line %s:%d:%d\n", sourceName, expected[i].Line, expected[i].Column)
+		formatted = formatted[:offset] + directive + formatted[offset:]
+	}
+
+	return formatted, moved
+}
+
+// addDecls add imports and declarations from compilers to compiled file. Imports are added to the line
+// of the last import (or package clause) so other declarations stay on their lines, declarations are added to the end.
+// Returns indexes of added imports in declPositions.
+func addDecls(fset *token.FileSet, file *ast.File, compiled string, state *fileState) (string, span) {
+	end, index := fset.Position(file.Name.End()).Offset, 1
+	for i, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			end, index = fset.Position(gen.End()).Offset, i+2
+		}
+	}
+
+	imports := strings.Builder{}
+	added := span{index, index}
+	for _, imp := range state.imports {
+		if hasImport(file, imp) {
+			continue
+		}
+
+		imports.WriteString("; import ")
+		if imp.Name != "" {
+			imports.WriteString(imp.Name + " ")
+		}
+		imports.WriteString(strconv.Quote(imp.Path))
+		added.end++
+	}
+
+	compiled = compiled[:end] + imports.String() + compiled[end:]
+	for _, decl := range state.decls {
+		if !strings.HasSuffix(compiled, "\n") {
+			compiled += "\n"
+		}
+
+		compiled += "\n" + decl.directive + "\n" + decl.value + "\n"
+	}
+
+	return compiled, added
+}
+
+// hasImport return true if file imports package with the same name and path
+func hasImport(file *ast.File, imp Import) bool {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || path != imp.Path {
+			continue
+		}
+
+		var name string
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		if importName(name, path) == importName(imp.Name, imp.Path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importName return name of imported package. If name is empty it is guessed from path as goimports does:
+// last element without major version ("/v2") and "go-" prefix, cut at first non-identifier char ("yaml.v3" -> "yaml")
+func importName(name, importPath string) string {
+	if name != "" {
+		return name
+	}
+
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil && path.Dir(importPath) != "." {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+	if end := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); end != -1 {
+		base = base[:end]
+	}
+
+	return base
+}
+
+// declPositions return positions of package clause and top-level declarations
+func declPositions(file *ast.File) []token.Pos {
+	positions := []token.Pos{file.Package}
+	for _, decl := range file.Decls {
+		positions = append(positions, decl.Pos())
+	}
+
+	return positions
+}
+
+// movedDecl return index of first position which line is not expected one
+func movedDecl(fset *token.FileSet, positions []token.Pos, expected []token.Position) int {
+	for i, pos := range positions {
+		if i < len(expected) && fset.Position(pos).Line != expected[i].Line {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// syntaxDiagnostic map syntax error in compiled file to template position
+func syntaxDiagnostic(fileInfo File, src, compiled string, blocks []outputBlock, err error) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return &Diagnostic{File: fileInfo.Path, Severity: SeverityError, Message: err.Error()}
+	}
+
+	first := list[0]
+	diagnostic := &Diagnostic{
+		File:     fileInfo.Path,
+		Line:     first.Pos.Line,
+		Column:   first.Pos.Column,
+		Severity: SeverityError,
+		Message:  "invalid Go code: " + first.Msg,
+	}
+
+	offset := first.Pos.Offset
+	for _, out := range blocks {
+		if offset < out.start || offset > out.end {
+			continue
+		}
+
+		diagnostic.Block = src[out.block.nameStart:out.block.nameEnd]
+		diagnostic.Line, diagnostic.Column = position(src, out.block.start)
+		diagnostic.Suggestion = "check Go code in the block"
+
+		if exprOffset, expr, ok := findExpression(src, compiled[out.start:out.end], offset-out.start, out.block); ok {
+			diagnostic.Line, diagnostic.Column = position(src, exprOffset)
+			diagnostic.Suggestion = "check Go expression: " + expr
+		}
+
+		break
+	}
+
+	return diagnostic
+}
+
+// findExpression find template expression which compiled code contains offset (or the nearest one before it).
+// Returns offset in GOS file.
+func findExpression(src, compiled string, offset int, block *blockNode) (int, string, bool) {
+	type occurrence struct {
+		start, end int // in compiled code
+		expr       span
+	}
+
+	var occurrences []occurrence
+	for _, expr := range blockExpressions(block) {
+		value := src[expr.start:expr.end]
+		trimmed := strings.TrimSpace(value)
+		if len(trimmed) == 0 {
+			continue
+		}
+
+		exprStart := expr.start + strings.Index(value, trimmed)
+		for from := 0; from < len(compiled); {
+			i := strings.Index(compiled[from:], trimmed)
+			if i == -1 {
+				break
+			}
+
+			occurrences = append(occurrences, occurrence{
+				start: from + i,
+				end:   from + i + len(trimmed),
+				expr:  span{exprStart, exprStart + len(trimmed)},
+			})
+			from += i + 1
+		}
+	}
+
+	// the innermost expression containing offset
+	best := -1
+	for i, occ := range occurrences {
+		if occ.start > offset || occ.end < offset {
+			continue
+		}
+
+		if best == -1 || occ.start > occurrences[best].start {
+			best = i
+		}
+	}
+
+	if best != -1 {
+		occ := occurrences[best]
+		return occ.expr.start + offset - occ.start, src[occ.expr.start:occ.expr.end], true
+	}
+
+	// the nearest expression before offset
+	for i, occ := range occurrences {
+		if occ.end > offset {
+			continue
+		}
+
+		if best == -1 || occ.end > occurrences[best].end {
+			best = i
+		}
+	}
+
+	if best != -1 {
+		occ := occurrences[best]
+		return occ.expr.start, src[occ.expr.start:occ.expr.end], true
+	}
+
+	return 0, "", false
+}
+
+// blockExpressions return expressions of block and its nested blocks
+func blockExpressions(block *blockNode) []span {
+	expressions := append([]span{}, block.expressions...)
+	for _, child := range block.childes {
+		expressions = append(expressions, blockExpressions(child)...)
+	}
+
+	return expressions
+}
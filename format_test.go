@@ -0,0 +1,28 @@
+package gasx
+
+import "testing"
+
+func TestImportName(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		want       string
+	}{
+		{"", "fmt", "fmt"},
+		{"", "net/http", "http"},
+		{"", "gopkg.in/yaml.v3", "yaml"},
+		{"", "github.com/go-chi/chi/v5", "chi"},
+		{"", "github.com/mattn/go-colorable", "colorable"},
+		{"", "github.com/user/lib.go", "lib"},
+		{"", "v2", "v2"},
+		{"", "github.com/user/v2x", "v2x"},
+		{"y", "gopkg.in/yaml.v3", "y"},
+		{"_", "embed", "_"},
+	}
+
+	for _, test := range tests {
+		if got := importName(test.name, test.importPath); got != test.want {
+			t.Errorf("importName(%q, %q) = %q, want %q", test.name, test.importPath, got, test.want)
+		}
+	}
+}
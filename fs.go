@@ -0,0 +1,374 @@
+package gasx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WriteFS file system with write operations. Names are slash-separated paths as in fs.FS.
+type WriteFS interface {
+	fs.FS
+
+	// WriteFile write file atomically, file is created if it doesn't exist
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+
+	// MkdirAll create directory with parents
+	MkdirAll(name string, perm fs.FileMode) error
+
+	// Remove remove file or empty directory
+	Remove(name string) error
+
+	// RemoveAll remove file or directory with its content, it isn't error if name doesn't exist
+	RemoveAll(name string) error
+}
+
+// OS operating system file system. Unlike os.DirFS it accepts absolute paths and paths relative to working directory.
+var OS WriteFS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// WriteFile write body to temporary file which is renamed to filename
+func (osFS) WriteFile(filename string, data []byte, perm fs.FileMode) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		if os.IsPermission(err) {
+			LogLevel(LevelWarn, "Run: \"chmod 777 -R $GOPATH/pkg/mod\"")
+		}
+
+		return err
+	}
+
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Chmod(perm)
+	}
+
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filename)
+	}
+
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+// MemFS in-memory file system for tests and tools which don't touch disk. Safe for concurrent use.
+// Parent directories of files exist implicitly.
+type MemFS struct {
+	mu    sync.RWMutex
+	files map[string]*memFile
+}
+
+// memFile file or directory of MemFS, data isn't changed after creation
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS create in-memory file system with files bodies by names
+func NewMemFS(files map[string]string) *MemFS {
+	mem := &MemFS{files: make(map[string]*memFile)}
+	for name, body := range files {
+		mem.files[path.Clean(name)] = &memFile{data: []byte(body), mode: 0644, modTime: time.Now()}
+	}
+
+	return mem
+}
+
+func (mem *MemFS) Open(name string) (fs.File, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+
+	info, err := mem.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		entries, _ := mem.readDir("open", name)
+		return &memDir{info: info, entries: entries}, nil
+	}
+
+	return &memReader{info: info, Reader: bytes.NewReader(info.file.data)}, nil
+}
+
+func (mem *MemFS) ReadFile(name string) ([]byte, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+
+	info, err := mem.stat("read", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+
+	return append([]byte{}, info.file.data...), nil
+}
+
+func (mem *MemFS) Stat(name string) (fs.FileInfo, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+
+	return mem.stat("stat", name)
+}
+
+func (mem *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+
+	return mem.readDir("readdir", name)
+}
+
+// stat return info of file or directory, directory exists if it is created or has files
+func (mem *MemFS) stat(op, name string) (*memInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if file, ok := mem.files[name]; ok {
+		return &memInfo{name: path.Base(name), file: file}, nil
+	}
+
+	for other := range mem.files {
+		if strings.HasPrefix(other, name+"/") {
+			return &memInfo{name: path.Base(name), file: &memFile{mode: fs.ModeDir | 0755}}, nil
+		}
+	}
+	if name == "." {
+		return &memInfo{name: ".", file: &memFile{mode: fs.ModeDir | 0755}}, nil
+	}
+
+	return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+// readDir return sorted entries of directory
+func (mem *MemFS) readDir(op, name string) ([]fs.DirEntry, error) {
+	info, err := mem.stat(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: op, Path: name, Err: errors.New("not a directory")}
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+
+	children := make(map[string]bool)
+	for other := range mem.files {
+		if strings.HasPrefix(other, prefix) {
+			children[strings.SplitN(strings.TrimPrefix(other, prefix), "/", 2)[0]] = true
+		}
+	}
+
+	var entries []fs.DirEntry
+	for child := range children {
+		childInfo, _ := mem.stat(op, prefix+child)
+		entries = append(entries, childInfo)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (mem *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if info, err := mem.stat("write", name); err == nil && info.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("is a directory")}
+	}
+
+	// readers of old file keep old data
+	mem.files[name] = &memFile{data: append([]byte{}, data...), mode: perm, modTime: time.Now()}
+	return nil
+}
+
+func (mem *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if file, ok := mem.files[dir]; ok && !file.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: errors.New("not a directory")}
+		}
+
+		mem.files[dir] = &memFile{mode: fs.ModeDir | perm, modTime: time.Now()}
+	}
+
+	return nil
+}
+
+func (mem *MemFS) Remove(name string) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	_, err := mem.stat("remove", name)
+	if err != nil {
+		return err
+	}
+
+	for other := range mem.files {
+		if strings.HasPrefix(other, name+"/") {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+
+	delete(mem.files, name)
+	return nil
+}
+
+func (mem *MemFS) RemoveAll(name string) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	for other := range mem.files {
+		if other == name || name == "." || strings.HasPrefix(other, name+"/") {
+			delete(mem.files, other)
+		}
+	}
+
+	return nil
+}
+
+// memInfo fs.FileInfo and fs.DirEntry of MemFS file
+type memInfo struct {
+	name string
+	file *memFile
+}
+
+func (info *memInfo) Name() string               { return info.name }
+func (info *memInfo) Size() int64                { return int64(len(info.file.data)) }
+func (info *memInfo) Mode() fs.FileMode          { return info.file.mode }
+func (info *memInfo) ModTime() time.Time         { return info.file.modTime }
+func (info *memInfo) IsDir() bool                { return info.file.mode.IsDir() }
+func (info *memInfo) Sys() interface{}           { return nil }
+func (info *memInfo) Type() fs.FileMode          { return info.file.mode.Type() }
+func (info *memInfo) Info() (fs.FileInfo, error) { return info, nil }
+
+// memReader opened MemFS file
+type memReader struct {
+	*bytes.Reader
+	info *memInfo
+}
+
+func (file *memReader) Stat() (fs.FileInfo, error) { return file.info, nil }
+func (file *memReader) Close() error               { return nil }
+
+// memDir opened MemFS directory
+type memDir struct {
+	info    *memInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (dir *memDir) Stat() (fs.FileInfo, error) { return dir.info, nil }
+func (dir *memDir) Close() error               { return nil }
+
+func (dir *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: dir.info.name, Err: errors.New("is a directory")}
+}
+
+func (dir *memDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	entries := dir.entries[dir.offset:]
+	if count > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < len(entries) {
+		entries = entries[:count]
+	}
+
+	dir.offset += len(entries)
+	return entries, nil
+}
+
+// ExistsFS return true if file exists in file system
+func ExistsFS(fsys fs.FS, name string) bool {
+	_, err := fs.Stat(fsys, name)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// sourceFS return file system with GOS files
+func (builder *Builder) sourceFS() fs.FS {
+	if builder.FS == nil {
+		return OS
+	}
+
+	return builder.FS
+}
+
+// outFS return file system for compiled files
+func (builder *Builder) outFS() WriteFS {
+	if builder.OutFS != nil {
+		return builder.OutFS
+	}
+
+	if fsys, ok := builder.FS.(WriteFS); ok {
+		return fsys
+	}
+
+	return OS
+}
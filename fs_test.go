@@ -0,0 +1,54 @@
+package gasx
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestMemFS(t *testing.T) {
+	mem := NewMemFS(map[string]string{
+		"app/a.gos":     "package app",
+		"app/sub/b.gos": "package sub",
+		"index.html":    "<html></html>",
+	})
+	if err := mem.MkdirAll("dist/static", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.WriteFile("dist/main.css", []byte("a{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fstest.TestFS(mem, "app/a.gos", "app/sub/b.gos", "index.html", "dist/main.css", "dist/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mem.Remove("app"); err == nil {
+		t.Errorf("expected error for removing not empty directory")
+	}
+	if err := mem.WriteFile("app", nil, 0644); err == nil {
+		t.Errorf("expected error for writing directory")
+	}
+}
+
+func TestClearDirFS(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		exists bool
+	}{
+		{"missing dir is created", map[string]string{}, true},
+		{"existing dir is removed", map[string]string{"dist/main.css": "a{}"}, false},
+	}
+
+	for _, test := range tests {
+		mem := NewMemFS(test.files)
+		if err := ClearDirFS(mem, "dist"); err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if ExistsFS(mem, "dist") != test.exists {
+			t.Errorf("%s: dist exists = %v", test.name, !test.exists)
+		}
+	}
+}
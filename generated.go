@@ -0,0 +1,117 @@
+package gasx
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// GeneratedHeader first line of compiled files
+const GeneratedHeader = "// Code generated by gasx; DO NOT EDIT."
+
+var sourceRgxp = regexp.MustCompile(`^// Source: (.+) \(sha256:([0-9a-f]+)\)$`)
+
+// generatedHeader return header of compiled file with source name and hash
+func generatedHeader(sourceName, src string) string {
+	return GeneratedHeader + "\n" + fmt.Sprintf("// Source: %s (sha256:%s)", sourceName, hashString(src)) + "\n\n"
+}
+
+// GeneratedSource return source file path and hash from compiled file header. Returns false if file isn't generated by gasx.
+func GeneratedSource(path string) (string, string, bool, error) {
+	return GeneratedSourceFS(OS, path)
+}
+
+// GeneratedSourceFS is GeneratedSource for file in fsys
+func GeneratedSourceFS(fsys fs.FS, name string) (string, string, bool, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", "", false, err
+	}
+	defer file.Close()
+
+	// header can be anywhere before package clause (e.g. after license)
+	lines := bufio.NewScanner(file)
+	for lines.Scan() && !strings.HasPrefix(lines.Text(), "package ") {
+		if lines.Text() != GeneratedHeader {
+			continue
+		}
+
+		if !lines.Scan() {
+			break
+		}
+
+		match := sourceRgxp.FindStringSubmatch(lines.Text())
+		if match == nil {
+			break
+		}
+
+		source := match[1]
+		if !path.IsAbs(source) {
+			source = path.Join(path.Dir(name), source)
+		}
+
+		return source, match[2], true, nil
+	}
+
+	return "", "", false, lines.Err()
+}
+
+// RemoveStaleFiles remove compiled files "*_gas.go" (only with gasx header) which source doesn't exist in root directory
+func RemoveStaleFiles(root string) ([]string, error) {
+	return RemoveStaleFilesFS(OS, root)
+}
+
+// RemoveStaleFilesFS is RemoveStaleFiles for directory in fsys
+func RemoveStaleFilesFS(fsys WriteFS, root string) ([]string, error) {
+	var removed []string
+	err := fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !strings.HasSuffix(name, "_gas.go") {
+			return nil
+		}
+
+		source, _, ok, err := GeneratedSourceFS(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		if !ok || ExistsFS(fsys, source) {
+			return nil
+		}
+
+		err = fsys.Remove(name)
+		if err != nil {
+			return err
+		}
+
+		removed = append(removed, name)
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("error while removing stale files: %s", err.Error())
+	}
+
+	return removed, nil
+}
+
+// removeStaleFiles remove stale compiled files in Builder.StaleDirs
+func (builder *Builder) removeStaleFiles() error {
+	for _, dir := range builder.StaleDirs {
+		removed, err := RemoveStaleFilesFS(builder.outFS(), dir)
+		for _, file := range removed {
+			LogLevel(LevelDebug, "removed stale file "+file)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package gasx
+
+import (
+	"path"
+	"testing"
+)
+
+func TestStaleDirs(t *testing.T) {
+	fsys := NewMemFS(map[string]string{
+		"app/a.gos":       "package app\n\nvar A = 1\n",
+		"app/old_gas.go":  generatedHeader("old.gos", "") + "package app\n",
+		"app/user_gas.go": "package app\n",
+	})
+	files := []File{{Path: "app/a.gos", Extension: "gos"}}
+
+	tests := []struct {
+		name      string
+		staleDirs []string
+		exists    map[string]bool
+	}{
+		{"disabled", nil, map[string]bool{"app/old_gas.go": true, "app/user_gas.go": true, "app/a_gas.go": true}},
+		{"enabled", []string{"app"}, map[string]bool{"app/old_gas.go": false, "app/user_gas.go": true, "app/a_gas.go": true}},
+	}
+
+	for _, test := range tests {
+		builder := &Builder{FS: fsys, StaleDirs: test.staleDirs}
+		err := builder.ParseFiles(files)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		for name, exists := range test.exists {
+			if ExistsFS(fsys, name) != exists {
+				t.Errorf("%s: %s exists = %v", test.name, name, !exists)
+			}
+		}
+	}
+}
+
+func TestStaleDirsMovedOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"sub dir", "app/gen/a_gas.go"},
+		{"sibling dir", "gen/a_gas.go"},
+		{"same dir", "app/a_gas.go"},
+	}
+
+	for _, test := range tests {
+		fsys := NewMemFS(map[string]string{"app/a.gos": "package app\n\nvar A = 1\n"})
+		if err := fsys.MkdirAll(path.Dir(test.output), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		builder := &Builder{
+			FS:        fsys,
+			StaleDirs: []string{"."},
+			BeforeFile: []FileHook{func(ctx *FileContext) error {
+				ctx.Output = test.output
+				return nil
+			}},
+		}
+
+		err := builder.ParseFiles([]File{{Path: "app/a.gos", Extension: "gos"}})
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if !ExistsFS(fsys, test.output) {
+			t.Errorf("%s: compiled file is removed as stale", test.name)
+			continue
+		}
+
+		source, _, ok, err := GeneratedSourceFS(fsys, test.output)
+		if err != nil || !ok || source != "app/a.gos" {
+			t.Errorf("%s: header source %q, want app/a.gos", test.name, source)
+		}
+	}
+}
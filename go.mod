@@ -1,6 +1,6 @@
 module github.com/gascore/gasx
 
-go 1.12
+go 1.16
 
 require (
 	github.com/fatih/color v1.7.0
@@ -0,0 +1,43 @@
+package gasx
+
+// FileContext GOS file passing through builder hooks
+type FileContext struct {
+	File File
+
+	// Source GOS file body
+	Source string
+
+	// Output path where compiled file is written (file in overlay dir in overlay mode)
+	Output string
+
+	// Compiled compiled file body, empty in BeforeFile hooks
+	Compiled string
+
+	// Artifacts compilers artifacts, nil in BeforeFile hooks
+	Artifacts map[string]string
+
+	// Cached file is skipped by cache: it isn't compiled and written, Compiled and Artifacts are restored.
+	// AfterCompile hooks aren't called for cached files, their changes are already in Compiled.
+	Cached bool
+}
+
+// FileHook per-file builder hook. Hook can change context fields, returned error aborts file compilation.
+type FileHook func(*FileContext) error
+
+// runHooks run hooks one by one, stops on first error
+func runHooks(hooks []FileHook, ctx *FileContext) error {
+	for _, hook := range hooks {
+		err := hook(ctx)
+		if err == nil {
+			continue
+		}
+
+		if _, ok := err.(*Diagnostic); ok {
+			return err
+		}
+
+		return &Diagnostic{File: ctx.File.Path, Severity: SeverityError, Message: "error in file hook: " + err.Error()}
+	}
+
+	return nil
+}
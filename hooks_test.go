@@ -0,0 +1,56 @@
+package gasx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHooksWithCache(t *testing.T) {
+	fsys := NewMemFS(map[string]string{
+		"app/a.gos": "package app\n\nvar A = 1\n",
+		"app/b.gos": "package app\n\nvar B = 2\n",
+	})
+	files := []File{{Path: "app/a.gos", Extension: "gos"}, {Path: "app/b.gos", Extension: "gos"}}
+
+	var compiled, written []string
+	builder := &Builder{
+		FS:       fsys,
+		CacheDir: ".cache",
+		AfterCompile: []FileHook{func(ctx *FileContext) error {
+			compiled = append(compiled, ctx.File.Path)
+			ctx.Compiled += "// hook\n"
+			return nil
+		}},
+		AfterWrite: []FileHook{func(ctx *FileContext) error {
+			if !strings.HasSuffix(ctx.Compiled, "// hook\n") {
+				t.Errorf("%s: compiled file without AfterCompile changes, cached: %v", ctx.File.Path, ctx.Cached)
+			}
+			written = append(written, ctx.File.Path)
+			return nil
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		compiled int
+	}{
+		{"first build", 2},
+		{"cached build", 0},
+	}
+
+	for _, test := range tests {
+		compiled, written = nil, nil
+
+		err := builder.ParseFiles(files)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if len(compiled) != test.compiled {
+			t.Errorf("%s: AfterCompile is called for %q", test.name, compiled)
+		}
+		if strings.Join(written, ",") != "app/a.gos,app/b.gos" {
+			t.Errorf("%s: AfterWrite is called for %q", test.name, written)
+		}
+	}
+}
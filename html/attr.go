@@ -1,8 +1,10 @@
 package html
 
 import (
+	"sort"
 	"strings"
 
+	"github.com/gascore/gasx"
 	"golang.org/x/net/html"
 )
 
@@ -43,6 +45,9 @@ func getXAttr(attrs []html.Attribute, x string) string {
 type ElementInfo struct {
 	Tag string
 
+	// Block special block containing element
+	Block *gasx.BlockInfo
+
 	IsComment bool
 
 	Attrs map[string]string
@@ -95,7 +100,8 @@ func (info *ElementInfo) RenderHandlers() string {
 	}
 
 	var out string
-	for key, val := range info.Handlers {
+	for _, key := range sortedKeys(info.Handlers) {
+		val := info.Handlers[key]
 		out += `"` + key + `": func(e gas.Event) {` + val + ` },`
 	}
 
@@ -109,11 +115,13 @@ func (info *ElementInfo) RenderAttrs() string {
 	}
 
 	var out string
-	for key, val := range info.Attrs {
+	for _, key := range sortedKeys(info.Attrs) {
+		val := info.Attrs[key]
 		out += `"` + key + `": "` + strings.Replace(val, `"`, `\"`, -1) + `",`
 	}
 
-	for key, val := range info.Binds {
+	for _, key := range sortedKeys(info.Binds) {
+		val := info.Binds[key]
 		out += `"` + key + `": ` + val + `,`
 	}
 
@@ -142,6 +150,7 @@ func (info *ElementInfo) RenderIsPointer() string {
 func GetElementInfo(tag string, attrs []html.Attribute, handler HTMLHandler) *ElementInfo {
 	info := &ElementInfo{
 		Tag:      tag,
+		Block:    handler.info,
 		Handlers: make(map[string]string),
 		Binds:    make(map[string]string),
 		Attrs:    make(map[string]string),
@@ -208,3 +217,14 @@ func GetElementInfo(tag string, attrs []html.Attribute, handler HTMLHandler) *El
 
 	return info
 }
+
+// sortedKeys return map keys in stable order, so compiled file is the same on every build
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
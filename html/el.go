@@ -1,7 +1,6 @@
 package html
 
 import (
-	"errors"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -26,7 +25,7 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 		info := GetElementInfo("e", t.Attr, handler)
 		out := info.Attrs["run"]
 		if out == "" {
-			return nil, "", errors.New("invalid \"e\" element: no \"run\" attribute")
+			return nil, "", handler.diagnostic(t, "", "invalid \"e\" element: no \"run\" attribute", "add component call: <e run=\"component()\">")
 		}
 
 		var slots string
@@ -68,7 +67,8 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 
 		if len(t.Attr) > 1 { // not only "run" attribute
 			var attrsString string
-			for aKey, aVal := range info.Attrs {
+			for _, aKey := range sortedKeys(info.Attrs) {
+				aVal := info.Attrs[aKey]
 				if aKey == "run" {
 					continue
 				}
@@ -91,7 +91,7 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 	case "g-switch":
 		runAttribute := getXAttr(t.Attr, "run")
 		if len(runAttribute) == 0 {
-			return nil, "", errors.New("invalid g-switch: \"run\" attribute is undefined")
+			return nil, "", handler.diagnostic(t, "", "invalid g-switch: \"run\" attribute is undefined", "add switch value: <g-switch run=\"value\">")
 		}
 
 		var switchOut string
@@ -106,15 +106,16 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 			}
 
 			if len(attrs.CaseData) == 0 && !attrs.CaseDefaultData {
-				return false, errors.New("invalid g-switch child")
+				return false, handler.diagnostic(c, "", "invalid g-switch child", "every g-switch child must have \"g-case\" or \"g-default\" attribute")
 			}
 
+			cOut = handler.lineDirective(c) + cOut
 			if len(attrs.CaseData) != 0 {
-				switchOut += "case " + attrs.CaseData + ": \n\treturn " + cOut + "\n"
+				switchOut += "case " + handler.lineDirective(c) + attrs.CaseData + ": return " + cOut + "; "
 			}
 
 			if attrs.CaseDefaultData {
-				switchOut += "default: \n\treturn " + cOut + "\n"
+				switchOut += "default: return " + cOut + "; "
 			}
 
 			return false, nil
@@ -125,7 +126,7 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 			return nil, "", err
 		}
 
-		return &ElementInfo{Tag: "g-switch"}, "func()interface{}{\n\tswitch " + runAttribute + " {\n" + switchOut + "}\n\treturn nil\n}()", nil
+		return &ElementInfo{Tag: "g-switch"}, "func()interface{}{ switch " + runAttribute + " { " + switchOut + "}; return nil }()", nil
 	}
 
 	elementInfo := GetElementInfo(t.Data, t.Attr, handler)
@@ -136,10 +137,15 @@ func executeEl(t *html.Node, handler HTMLHandler) (*ElementInfo, string, error)
 		return nil, "", err
 	}
 
+	if len(childesOut) != 0 {
+		tBody += "\n"
+	}
+
 	elOut := returnOutElement(tBody + childesOut)
 
 	if len(elementInfo.ForData) != 0 {
-		return elementInfo, returnOutFOR(elementInfo.ForData, elOut), nil
+		// gofmt puts loop clause on its own line
+		return elementInfo, returnOutFOR(handler.lineDirective(t)+elementInfo.ForData, elOut), nil
 	}
 
 	return elementInfo, elOut, nil
@@ -190,6 +196,9 @@ func genChildes(childes []*html.Node, beforeHandler func(*html.Node) (bool, erro
 			continue
 		}
 
+		// template lines of code split by gofmt
+		cOut = handler.lineDirective(c) + cOut
+
 		needComma := true
 
 		if info == nil {
@@ -206,19 +215,19 @@ func genChildes(childes []*html.Node, beforeHandler func(*html.Node) (bool, erro
 					closeLogicBlock()
 				}
 
-				logicBlock = "if " + info.IfData + " { return " + cOut + " }"
+				logicBlock = "if " + handler.lineDirective(c) + info.IfData + " { return " + cOut + " }"
 				haveIf = true
 				continue
 			case len(info.ElseIfData) != 0:
 				if !haveIf {
-					return "", errors.New("invalid g-else-if: no g-if before")
+					return "", handler.diagnostic(c, gElseIf, "invalid g-else-if: no g-if before", "put g-else-if right after element with g-if")
 				}
 
 				logicBlock += " else if " + info.ElseIfData + " { return " + cOut + " }"
 				continue
 			case info.ElseData:
 				if !haveIf {
-					return "", errors.New("invalid g-else: no g-if before")
+					return "", handler.diagnostic(c, gElse, "invalid g-else: no g-if before", "put g-else right after element with g-if or g-else-if")
 				}
 
 				logicBlock += " else { return " + cOut + " }"
@@ -230,7 +239,7 @@ func genChildes(childes []*html.Node, beforeHandler func(*html.Node) (bool, erro
 
 		mainBlock += cOut
 		if needComma {
-			mainBlock += ","
+			mainBlock += ",\n" // one child per line after gofmt
 		}
 
 		if haveIf {
@@ -3,14 +3,25 @@ package html
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/gascore/gasx"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/atom"
 )
 
+// GasPackage import path of gas package used by compiled blocks
+const GasPackage = "github.com/gascore/gas"
+
+// BlockNames names of blocks compiled by HTMLCompiler
+var BlockNames = []string{"html", "htmlF", "htmlEl"}
+
+// HTMLCompiler html blocks compiler. Safe for concurrent use, hooks must be safe for concurrent use too.
 type HTMLCompiler struct {
+	mu sync.RWMutex
+
 	onAttribute   []func(key, val string, info *gasx.BlockInfo)
 	onNode        []func(*html.Node)
 	onElementInfo []func(*ElementInfo)
@@ -21,43 +32,115 @@ func NewCompiler() *HTMLCompiler {
 }
 
 func (c *HTMLCompiler) AddOnAttribute(f func(string, string, *gasx.BlockInfo)) {
+	c.mu.Lock()
 	c.onAttribute = append(c.onAttribute, f)
+	c.mu.Unlock()
 }
 
 func (c *HTMLCompiler) AddOnNode(f func(*html.Node)) {
+	c.mu.Lock()
 	c.onNode = append(c.onNode, f)
+	c.mu.Unlock()
 }
 
 func (c *HTMLCompiler) AddOnElementInfo(f func(*ElementInfo)) {
+	c.mu.Lock()
 	c.onElementInfo = append(c.onElementInfo, f)
+	c.mu.Unlock()
 }
 
 type HTMLHandler struct {
 	info *gasx.BlockInfo
 	c    *HTMLCompiler
+
+	// offsets nodes positions in block value
+	offsets map[*html.Node]nodeToken
 }
 
 func (handler *HTMLHandler) runOnAttribute(key, val string) {
-	for _, f := range handler.c.onAttribute {
+	handler.c.mu.RLock()
+	hooks := handler.c.onAttribute
+	handler.c.mu.RUnlock()
+
+	for _, f := range hooks {
 		f(key, val, handler.info)
 	}
 }
 
 func (handler *HTMLHandler) runOnNode(node *html.Node) {
-	for _, f := range handler.c.onNode {
+	handler.c.mu.RLock()
+	hooks := handler.c.onNode
+	handler.c.mu.RUnlock()
+
+	for _, f := range hooks {
 		f(node)
 	}
 }
 
 func (handler *HTMLHandler) runOnElementInfo(info *ElementInfo) {
-	for _, f := range handler.c.onElementInfo {
+	handler.c.mu.RLock()
+	hooks := handler.c.onElementInfo
+	handler.c.mu.RUnlock()
+
+	for _, f := range hooks {
 		f(info)
 	}
 }
 
+var tagRgxp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+
+// Args arguments accepted by html blocks
+var Args = []gasx.BlockArgSpec{
+	{Name: "root", Usage: "wrap block content into element with the tag"},
+}
+
+// CacheKey return compiler version for gasx.Builder cache key, bump it when compiled blocks change.
+// Hooks changing compiled blocks add their own keys by gasx.Builder.AddCacheKey.
+func (c *HTMLCompiler) CacheKey() string {
+	return "html/2:" + GasPackage
+}
+
+// Register add compiler to builder compilers registry for BlockNames, declare its Args and add its CacheKey
+func (c *HTMLCompiler) Register(builder *gasx.Builder) {
+	builder.Register(c.Block(), BlockNames...)
+	builder.AddCacheKey(c.CacheKey)
+
+	for _, name := range BlockNames {
+		builder.DeclareArgs(name, Args...)
+	}
+}
+
+// diagnostic return error pointing at node or its attribute if attr isn't empty
+func (handler *HTMLHandler) diagnostic(node *html.Node, attr, msg, suggestion string) error {
+	diagnostic := &gasx.Diagnostic{
+		Severity:   gasx.SeverityError,
+		Message:    msg,
+		Suggestion: suggestion,
+	}
+
+	if token, ok := handler.offsets[node]; ok {
+		diagnostic.Offset = token.offset
+		if attr != "" {
+			diagnostic.Offset += attrOffset(token.raw, attr)
+		}
+	}
+
+	return diagnostic
+}
+
+// lineDirective return line directive pointing at node, code generated from node follows it
+func (handler *HTMLHandler) lineDirective(node *html.Node) string {
+	token, ok := handler.offsets[node]
+	if !ok {
+		return ""
+	}
+
+	return handler.info.LineDirective(token.offset)
+}
+
 func (c *HTMLCompiler) Block() gasx.BlockCompiler {
 	return func(info *gasx.BlockInfo) (string, error) {
-		if info.Name != "html" && info.Name != "htmlF" && info.Name != "htmlEl" {
+		if !gasx.InArrayString(info.Name, BlockNames) {
 			return info.Value, nil
 		}
 
@@ -67,25 +150,42 @@ func (c *HTMLCompiler) Block() gasx.BlockCompiler {
 			DataAtom: atom.Div,
 		})
 		if err != nil {
-			return "", fmt.Errorf("error while parsing html block: %s", err.Error())
+			return "", &gasx.Diagnostic{Severity: gasx.SeverityError, Message: "error while parsing html block: " + err.Error()}
 		}
 
 		handler := HTMLHandler{
-			info: info,
-			c:    c,
+			info:    info,
+			c:       c,
+			offsets: nodeOffsets(info.Value, nodes),
 		}
 
 		out, err := genChildes(nodes, nil, handler)
 		if err != nil {
-			return "", fmt.Errorf("error while compiling html nodes")
+			if _, ok := err.(*gasx.Diagnostic); ok {
+				return "", err
+			}
+
+			return "", fmt.Errorf("error while compiling html nodes: %s", err.Error())
 		}
 
-		out = strings.TrimSuffix(out, ",") // $html
+		out = strings.TrimSuffix(strings.TrimSpace(out), ",") // $html
+
+		if root, ok := info.Arg("root"); ok { // $html(root=div)
+			if !tagRgxp.MatchString(root) {
+				return "", &gasx.Diagnostic{Severity: gasx.SeverityError, Message: "invalid root element \"" + root + "\""}
+			}
+
+			out = returnOutElement(`&gas.E{Tag:"` + root + `"},` + "\n" + out + ",\n")
+		}
 
 		if info.Name == "htmlF" { // $htmlF
 			out = "func() *gas.E {return " + out + "}"
 		}
 
+		if strings.Contains(out, "gas.") { // unused import is compile error
+			info.AddImport("", GasPackage)
+		}
+
 		return out, nil
 	}
 }
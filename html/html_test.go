@@ -0,0 +1,146 @@
+package html
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/gascore/gasx"
+)
+
+func TestLineDirectives(t *testing.T) {
+	src := "package app\n" +
+		"\n" +
+		"func A() interface{} {\n" +
+		"\treturn $html{\n" +
+		"\t\t<div>\n" +
+		"\t\t\t<p>{{ first }}</p>\n" +
+		"\t\t\t<p g-if=\"second\">x</p>\n" +
+		"\t\t\t<!-- comment -->\n" +
+		"\t\t\t<ul><li g-for=\"_, v := range third\">{{ v }}</li></ul>\n" +
+		"\t\t</div>\n" +
+		"\t\t<span>{{ fourth }}</span>\n" +
+		"\t\t<p g-if=\"fifth\">y</p>\n" +
+		"\t\t<g-switch run=\"sixth\">\n" +
+		"\t\t\t<i g-case=\"1\">a</i>\n" +
+		"\t\t\t<b g-case=\"seventh\">b</b>\n" +
+		"\t\t</g-switch>\n" +
+		"\t}$\n" +
+		"}\n"
+
+	builder := &gasx.Builder{FS: gasx.NewMemFS(map[string]string{"app/a.gos": src})}
+	NewCompiler().Register(builder)
+
+	out, err := builder.CompileFile(gasx.File{Path: "app/a.gos", Extension: "gos"}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "app/a_gas.go", out, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// type errors are reported at the same positions as by "go build"
+	lines := make(map[string]token.Position)
+	config := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			pkg := types.NewPackage(path, "gas")
+			pkg.MarkComplete()
+			return pkg, nil
+		}),
+		Error: func(err error) {
+			typeErr := err.(types.Error)
+			if name := strings.TrimPrefix(typeErr.Msg, "undefined: "); name != typeErr.Msg {
+				lines[name] = fset.Position(typeErr.Pos)
+			}
+		},
+	}
+	config.Check("app", fset, []*ast.File{file}, nil)
+
+	tests := []struct {
+		ident string
+		line  int
+	}{
+		{"first", 6},
+		{"second", 7},
+		{"third", 9},
+		{"fourth", 11},
+		{"fifth", 12},
+		{"seventh", 15},
+	}
+
+	for _, test := range tests {
+		pos, ok := lines[test.ident]
+		if !ok {
+			t.Errorf("%s: no error is reported:\n%s", test.ident, out)
+			continue
+		}
+
+		if pos.Filename != "app/a.gos" || pos.Line != test.line {
+			t.Errorf("%s: position %s:%d, want app/a.gos:%d\n%s", test.ident, pos.Filename, pos.Line, test.line, out)
+		}
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name   string
+		block  string
+		line   int
+		column int
+	}{
+		{
+			name:   "second element without run",
+			block:  "<e run=\"a()\"></e>\n\t\t<e title=\"run\"></e>",
+			line:   6,
+			column: 3,
+		},
+		{
+			name:   "repeated g-else",
+			block:  "<p g-if=\"a\">1</p>\n\t\t<p g-else>2</p>\n\t\t<p g-else>3</p>",
+			line:   7,
+			column: 6,
+		},
+		{
+			name:   "attribute name in value",
+			block:  "<p g-if=\"a\">1</p>\n\t\t<p g-else>2</p>\n\t\t<p title=\"g-else-if\" data-x='g-else-if' g-else-if=\"b\">3</p>",
+			line:   7,
+			column: 43,
+		},
+		{
+			name:   "repeated g-switch child",
+			block:  "<g-switch run=\"a\">\n\t\t\t<i g-case=\"1\">1</i>\n\t\t\t<i>2</i>\n\t\t</g-switch>",
+			line:   7,
+			column: 4,
+		},
+	}
+
+	for _, test := range tests {
+		src := "package app\n\nfunc A() interface{} {\n\treturn $html{\n\t\t" + test.block + "\n\t}$\n}\n"
+
+		builder := &gasx.Builder{}
+		NewCompiler().Register(builder)
+
+		_, err := builder.CompileFile(gasx.File{Path: "app/a.gos", Extension: "gos"}, src)
+		diagnostic, ok := err.(*gasx.Diagnostic)
+		if !ok {
+			t.Errorf("%s: error %v isn't diagnostic", test.name, err)
+			continue
+		}
+
+		if diagnostic.Line != test.line || diagnostic.Column != test.column {
+			t.Errorf("%s: position %d:%d, want %d:%d", test.name, diagnostic.Line, diagnostic.Column, test.line, test.column)
+		}
+	}
+}
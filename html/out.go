@@ -2,9 +2,8 @@ package html
 
 import (
 	"fmt"
-	"math/rand"
+	"hash/fnv"
 	"strings"
-	"time"
 )
 
 func returnOutStatic(out string) string {
@@ -29,7 +28,9 @@ func returnOutElement(out string) string {
 }
 
 func returnOutFOR(data, element string) string {
-	rand.Seed(time.Now().UnixNano())
-	c := fmt.Sprintf("c%d", rand.Int())
+	// name depends on loop only, so compiled file is the same on every build
+	h := fnv.New32a()
+	h.Write([]byte(data + element))
+	c := fmt.Sprintf("c%d", h.Sum32())
 	return fmt.Sprintf("func()[]interface{}{var %s []interface{}; for %s { %s = append(%s, %s) }; return %s}()", c, data, c, c, element, c)
 }
@@ -0,0 +1,156 @@
+package html
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// nodeToken position of parsed node in block value
+type nodeToken struct {
+	offset int
+
+	// raw node markup (start tag for elements)
+	raw string
+}
+
+// valueToken token of block value
+type valueToken struct {
+	nodeToken
+
+	typ  html.TokenType
+	data string
+}
+
+// nodeOffsets return positions of element, comment and text nodes in block value. Parser doesn't keep positions,
+// so value is tokenized again and tokens are matched with nodes in document order. Nodes added by parser
+// (e.g. "tbody") have no positions.
+func nodeOffsets(value string, nodes []*html.Node) map[*html.Node]nodeToken {
+	var (
+		tokens    []valueToken
+		offset    int
+		tokenizer = html.NewTokenizer(strings.NewReader(value))
+	)
+	for {
+		typ := tokenizer.Next()
+		if typ == html.ErrorToken {
+			// parser has already reported invalid markup
+			break
+		}
+
+		raw := string(tokenizer.Raw())
+		token := tokenizer.Token()
+		switch typ {
+		case html.StartTagToken, html.SelfClosingTagToken, html.CommentToken, html.TextToken:
+			tokens = append(tokens, valueToken{nodeToken: nodeToken{offset, raw}, typ: typ, data: token.Data})
+		}
+
+		offset += len(raw)
+	}
+
+	var (
+		offsets = make(map[*html.Node]nodeToken)
+		next    int
+	)
+
+	// match return index of token for node, search is stopped at the next start tag which isn't node's one
+	match := func(node *html.Node) int {
+		for i := next; i < len(tokens); i++ {
+			token := tokens[i]
+			isTag := token.typ == html.StartTagToken || token.typ == html.SelfClosingTagToken
+
+			switch node.Type {
+			case html.ElementNode:
+				if isTag {
+					if strings.EqualFold(token.data, node.Data) {
+						return i
+					}
+					return -1
+				}
+			case html.CommentNode:
+				if token.typ == html.CommentToken && token.data == node.Data {
+					return i
+				}
+			case html.TextNode:
+				if token.typ == html.TextToken && token.data == node.Data {
+					return i
+				}
+			}
+
+			if isTag {
+				return -1
+			}
+		}
+
+		return -1
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if i := match(node); i != -1 {
+			offsets[node] = tokens[i].nodeToken
+			next = i + 1
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+
+	return offsets
+}
+
+// attrOffset return offset of attribute name in start tag, 0 if there is no such attribute
+func attrOffset(raw, name string) int {
+	isSpace := func(i int) bool { return i < len(raw) && strings.IndexByte(" \t\r\n\f", raw[i]) != -1 }
+	isNameEnd := func(i int) bool { return i >= len(raw) || isSpace(i) || strings.IndexByte("=/>", raw[i]) != -1 }
+
+	// tag name
+	i := 1
+	for !isNameEnd(i) {
+		i++
+	}
+
+	for i < len(raw) {
+		for isSpace(i) || (i < len(raw) && raw[i] == '/') {
+			i++
+		}
+		if i >= len(raw) || raw[i] == '>' {
+			break
+		}
+
+		start := i
+		for i++; !isNameEnd(i); i++ {
+		}
+		if strings.EqualFold(raw[start:i], name) {
+			return start
+		}
+
+		for isSpace(i) {
+			i++
+		}
+		if i >= len(raw) || raw[i] != '=' {
+			continue
+		}
+		for i++; isSpace(i); i++ {
+		}
+
+		// value
+		if i < len(raw) && (raw[i] == '"' || raw[i] == '\'') {
+			end := strings.IndexByte(raw[i+1:], raw[i])
+			if end == -1 {
+				break
+			}
+			i += end + 2
+			continue
+		}
+		for i < len(raw) && !isSpace(i) && raw[i] != '>' {
+			i++
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,163 @@
+package gasx
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// LoaderOptions settings of generated wasm loader script
+type LoaderOptions struct {
+	// URL wasm binary URL, "main.wasm" if empty
+	URL string `json:"url"`
+
+	// Mount CSS selector of element for loading state and errors, document body if empty.
+	// Element "data-gasx-state" attribute is "loading", "running" or "error".
+	Mount string `json:"mount"`
+
+	// Args program arguments (os.Args[1:])
+	Args []string `json:"args"`
+
+	// Env program environment variables
+	Env map[string]string `json:"env"`
+
+	// Fallback instantiate wasm from ArrayBuffer if server doesn't send "application/wasm" content type
+	// or browser doesn't support streaming instantiation
+	Fallback bool `json:"fallback"`
+
+	// Progress global JS function ("onProgress", "app.progress") called with loaded and total bytes, total is 0 if it is unknown
+	Progress string `json:"progress"`
+
+	// ShowErrors show loading errors in mount element, errors are always logged to console
+	ShowErrors bool `json:"errors"`
+
+	// Integrity subresource integrity hash of wasm binary ("sha384-..."), see IntegrityHash
+	Integrity string `json:"integrity"`
+}
+
+// DefaultLoaderOptions return loader options with fallback and errors display enabled
+func DefaultLoaderOptions() LoaderOptions {
+	return LoaderOptions{
+		URL:        "main.wasm",
+		Fallback:   true,
+		ShowErrors: true,
+	}
+}
+
+// IntegrityHash return subresource integrity hash of data
+func IntegrityHash(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WASMLoader return script loading and running wasm binary, exec script must be loaded before it
+func WASMLoader(options LoaderOptions) string {
+	if options.URL == "" {
+		options.URL = "main.wasm"
+	}
+	if options.Args == nil {
+		options.Args = []string{}
+	}
+	if options.Env == nil {
+		options.Env = map[string]string{}
+	}
+
+	// options are only strings, string arrays and maps, so Marshal can't fail
+	data, _ := json.Marshal(options)
+
+	return `
+(() => {
+	const options = ` + string(data) + `;
+
+	const mount = () => {
+		if (typeof document === "undefined") {
+			return null;
+		}
+		return (options.mount && document.querySelector(options.mount)) || document.body || document.documentElement;
+	};
+
+	const setState = (state) => {
+		const el = mount();
+		if (el) {
+			el.setAttribute("data-gasx-state", state);
+		}
+	};
+
+	const progress = (loaded, total) => {
+		const callback = options.progress && options.progress.split(".").reduce((o, key) => (o == null ? o : o[key]), globalThis);
+		if (typeof callback === "function") {
+			// compressed responses Content-Length is less than loaded bytes
+			callback(loaded, loaded > total ? 0 : total);
+		}
+	};
+
+	const showError = (err) => {
+		console.error("gasx: failed to load " + options.url + ":", err);
+		setState("error");
+
+		const el = mount();
+		if (!options.errors || !el) {
+			return;
+		}
+
+		const box = document.createElement("pre");
+		box.className = "gasx-load-error";
+		box.setAttribute("style", "white-space:pre-wrap;margin:16px;padding:16px;color:#b00020;background:#fdecea;font:14px/1.5 monospace;");
+		box.textContent = "Failed to load application: " + (err && err.message ? err.message : err);
+		el.appendChild(box);
+	};
+
+	// track return response with body counting loaded bytes
+	const track = (response) => {
+		if (!response.ok) {
+			throw new Error(options.url + ": " + response.status + " " + response.statusText);
+		}
+		if (!options.progress || !response.body || typeof ReadableStream === "undefined") {
+			return response;
+		}
+
+		const total = Number(response.headers.get("Content-Length")) || 0;
+		const reader = response.body.getReader();
+		let loaded = 0;
+		const body = new ReadableStream({
+			pull(controller) {
+				return reader.read().then(({done, value}) => {
+					if (done) {
+						controller.close();
+						return;
+					}
+					loaded += value.byteLength;
+					progress(loaded, total);
+					controller.enqueue(value);
+				});
+			},
+			cancel(reason) {
+				return reader.cancel(reason);
+			},
+		});
+
+		return new Response(body, {status: response.status, statusText: response.statusText, headers: response.headers});
+	};
+
+	const instantiate = (go) => fetch(options.url, options.integrity ? {integrity: options.integrity} : {}).then(track).then((response) => {
+		const type = (response.headers.get("Content-Type") || "").split(";")[0].trim();
+		if (typeof WebAssembly.instantiateStreaming === "function" && (!options.fallback || type === "application/wasm")) {
+			return WebAssembly.instantiateStreaming(response, go.importObject);
+		}
+
+		return response.arrayBuffer().then((bytes) => WebAssembly.instantiate(bytes, go.importObject));
+	});
+
+	setState("loading");
+
+	const go = new Go();
+	go.argv = ["js"].concat(options.args);
+	go.env = Object.assign({}, go.env, options.env);
+
+	instantiate(go).then((result) => {
+		setState("running");
+		return go.run(result.instance);
+	}).catch(showError);
+})();
+`
+}
@@ -0,0 +1,125 @@
+package gasx
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scriptLoaderOptions return options of loader in exec script
+func scriptLoaderOptions(t *testing.T, script string) LoaderOptions {
+	const prefix = "const options = "
+	start := strings.Index(script, prefix)
+	if start == -1 {
+		t.Fatal("script doesn't have loader")
+	}
+	start += len(prefix)
+	end := start + strings.Index(script[start:], ";\n")
+
+	var options LoaderOptions
+	if err := json.Unmarshal([]byte(script[start:end]), &options); err != nil {
+		t.Fatal(err)
+	}
+
+	return options
+}
+
+func TestLoaderConfig(t *testing.T) {
+	configured := DefaultConfig().Wasm.Loader
+	configured.Mount = "#app"
+	configured.Args = []string{"-v"}
+	configured.Env = map[string]string{"MODE": "dev"}
+	configured.Progress = "app.progress"
+	configured.Fallback = false
+
+	tests := []struct {
+		name      string
+		loader    *LoaderOptions
+		wasmName  string
+		integrity bool
+		bundle    bool
+		want      LoaderOptions
+	}{
+		{
+			name:   "default config",
+			loader: DefaultConfig().Wasm.Loader.Options(),
+			want:   LoaderOptions{URL: "main.wasm", Args: []string{}, Env: map[string]string{}, Fallback: true, ShowErrors: true},
+		},
+		{
+			name: "no loader options",
+			want: LoaderOptions{URL: "main.wasm", Args: []string{}, Env: map[string]string{}, Fallback: true, ShowErrors: true},
+		},
+		{
+			name:     "wasm name",
+			loader:   DefaultConfig().Wasm.Loader.Options(),
+			wasmName: "app.wasm",
+			want:     LoaderOptions{URL: "app.wasm", Args: []string{}, Env: map[string]string{}, Fallback: true, ShowErrors: true},
+		},
+		{
+			name:   "configured",
+			loader: configured.Options(),
+			want: LoaderOptions{
+				URL:        "main.wasm",
+				Mount:      "#app",
+				Args:       []string{"-v"},
+				Env:        map[string]string{"MODE": "dev"},
+				Progress:   "app.progress",
+				ShowErrors: true,
+			},
+		},
+		{
+			name:      "bundle with integrity",
+			loader:    DefaultConfig().Wasm.Loader.Options(),
+			integrity: true,
+			bundle:    true,
+			want: LoaderOptions{
+				Args:       []string{},
+				Env:        map[string]string{},
+				Fallback:   true,
+				ShowErrors: true,
+				Integrity:  IntegrityHash([]byte("old binary")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		fsys := NewMemFS(map[string]string{
+			"dist/main.00000000.wasm": "old binary",
+			"dist/manifest.json":      `{"main.wasm": "main.00000000.wasm"}`,
+		})
+
+		dist := &Dist{Dir: "dist", WasmName: test.wasmName, Loader: test.loader, Integrity: test.integrity, FS: fsys}
+		if test.bundle {
+			// binary of previous build is bundled
+			dist.Bundle = &Bundle{HashLength: 8}
+		}
+
+		err := dist.Build(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		scriptName := "dist/wasm_exec.js"
+		if test.bundle {
+			body, _ := fs.ReadFile(fsys, "dist/manifest.json")
+			var manifest Manifest
+			json.Unmarshal(body, &manifest)
+			scriptName = "dist/" + manifest["wasm_exec.js"]
+
+			// loader fetches hashed binary
+			test.want.URL = manifest["main.wasm"]
+		}
+
+		script, err := fs.ReadFile(fsys, scriptName)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if got := scriptLoaderOptions(t, string(script)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: loader options\n%+v\nwant\n%+v", test.name, got, test.want)
+		}
+	}
+}
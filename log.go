@@ -0,0 +1,197 @@
+package gasx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Level log message level
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (level Level) String() string {
+	switch level {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(level))
+	}
+}
+
+// Logger output for builder messages. Must be safe for concurrent use.
+type Logger interface {
+	Log(level Level, msg string)
+}
+
+// DefaultLogger logger used by Log, LogError, Must and ErrorMsg
+var DefaultLogger Logger = &TextLogger{Level: LevelInfo}
+
+// TextLogger human-readable colored logger
+type TextLogger struct {
+	// Out messages output, os.Stdout if nil
+	Out io.Writer
+
+	// Level minimal level of printed messages
+	Level Level
+
+	// Quiet print only errors
+	Quiet bool
+
+	mu sync.Mutex
+}
+
+func (logger *TextLogger) Log(level Level, msg string) {
+	if !enabled(level, logger.Level, logger.Quiet) {
+		return
+	}
+
+	var prefix string
+	switch level {
+	case LevelDebug:
+		prefix = color.CyanString("DEBUG") + ": "
+	case LevelWarn:
+		prefix = color.YellowString("WARN") + ": "
+	case LevelError:
+		prefix = color.RedString("ERROR") + ": "
+	default:
+		prefix = color.BlueString("Builder:") + " "
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	fmt.Fprintln(output(logger.Out), prefix+msg)
+}
+
+// JSONLogger logger writing messages as JSON lines: {"time":"...","level":"info","msg":"..."}
+type JSONLogger struct {
+	// Out messages output, os.Stdout if nil
+	Out io.Writer
+
+	// Level minimal level of printed messages
+	Level Level
+
+	// Quiet print only errors
+	Quiet bool
+
+	mu sync.Mutex
+}
+
+type jsonLine struct {
+	Time  string `json:"time"`
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
+func (logger *JSONLogger) Log(level Level, msg string) {
+	if !enabled(level, logger.Level, logger.Quiet) {
+		return
+	}
+
+	line, err := json.Marshal(jsonLine{
+		Time:  time.Now().Format(time.RFC3339),
+		Level: level.String(),
+		Msg:   msg,
+	})
+	if err != nil {
+		return
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+
+	output(logger.Out).Write(append(line, '\n'))
+}
+
+// enabled return true if message with level must be printed
+func enabled(level, minLevel Level, quiet bool) bool {
+	if quiet {
+		return level >= LevelError
+	}
+
+	return level >= minLevel
+}
+
+func output(out io.Writer) io.Writer {
+	if out == nil {
+		return os.Stdout
+	}
+
+	return out
+}
+
+// Log print info message by DefaultLogger
+func Log(msg string) {
+	DefaultLogger.Log(LevelInfo, msg)
+}
+
+// LogLevel print message with level by DefaultLogger
+func LogLevel(level Level, msg string) {
+	DefaultLogger.Log(level, msg)
+}
+
+// LogError print error by DefaultLogger if it isn't nil. Returns err, it's non-panicking Must.
+func LogError(err error) error {
+	if err != nil {
+		DefaultLogger.Log(LevelError, err.Error())
+	}
+
+	return err
+}
+
+// LogErrorMsg print message with error level by DefaultLogger and return it as error, it's non-panicking ErrorMsg
+func LogErrorMsg(msg string) error {
+	DefaultLogger.Log(LevelError, msg)
+	return errors.New(msg)
+}
+
+// LogWriter return writer printing every written line by DefaultLogger with level, e.g. for WasmBuild.Log.
+// Line without line break is printed when it is completed by next writes.
+func LogWriter(level Level) io.Writer {
+	return &logWriter{level: level}
+}
+
+type logWriter struct {
+	level Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (writer *logWriter) Write(p []byte) (int, error) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	writer.buf = append(writer.buf, p...)
+	for {
+		end := bytes.IndexByte(writer.buf, '\n')
+		if end < 0 {
+			break
+		}
+
+		LogLevel(writer.level, string(writer.buf[:end]))
+		writer.buf = writer.buf[end+1:]
+	}
+
+	return len(p), nil
+}
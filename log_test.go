@@ -0,0 +1,119 @@
+package gasx
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type testLogger struct {
+	msgs []string
+}
+
+func (logger *testLogger) Log(level Level, msg string) {
+	logger.msgs = append(logger.msgs, level.String()+": "+msg)
+}
+
+func TestLogWriter(t *testing.T) {
+	defaultLogger := DefaultLogger
+	defer func() { DefaultLogger = defaultLogger }()
+
+	tests := []struct {
+		name   string
+		writes []string
+		want   []string
+	}{
+		{"lines", []string{"a\nb\n"}, []string{"warn: a", "warn: b"}},
+		{"split line", []string{"# app", "/main\n", "tail"}, []string{"warn: # app/main"}},
+		{"empty line", []string{"\n"}, []string{"warn: "}},
+	}
+
+	for _, test := range tests {
+		logger := &testLogger{}
+		DefaultLogger = logger
+
+		writer := LogWriter(LevelWarn)
+		for _, write := range test.writes {
+			writer.Write([]byte(write))
+		}
+
+		if strings.Join(logger.msgs, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%s: logged %q, want %q", test.name, logger.msgs, test.want)
+		}
+	}
+}
+
+func TestTextLogger(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	tests := []struct {
+		name  string
+		level Level
+		quiet bool
+		want  string
+	}{
+		{"debug", LevelDebug, false, "DEBUG: d\nBuilder: i\nWARN: w\nERROR: e\n"},
+		{"info", LevelInfo, false, "Builder: i\nWARN: w\nERROR: e\n"},
+		{"error", LevelError, false, "ERROR: e\n"},
+		{"quiet", LevelDebug, true, "ERROR: e\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		logger := &TextLogger{Out: &out, Level: test.level, Quiet: test.quiet}
+		logger.Log(LevelDebug, "d")
+		logger.Log(LevelInfo, "i")
+		logger.Log(LevelWarn, "w")
+		logger.Log(LevelError, "e")
+
+		if out.String() != test.want {
+			t.Errorf("%s: output %q, want %q", test.name, out.String(), test.want)
+		}
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		level Level
+		quiet bool
+		want  []string
+	}{
+		{"info", LevelInfo, false, []string{"info: i", "warn: w", "error: e\nline"}},
+		{"warn", LevelWarn, false, []string{"warn: w", "error: e\nline"}},
+		{"quiet", LevelInfo, true, []string{"error: e\nline"}},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		logger := &JSONLogger{Out: &out, Level: test.level, Quiet: test.quiet}
+		logger.Log(LevelDebug, "d")
+		logger.Log(LevelInfo, "i")
+		logger.Log(LevelWarn, "w")
+		logger.Log(LevelError, "e\nline")
+
+		// one JSON object per line
+		var got []string
+		for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+			var msg jsonLine
+			if err := json.Unmarshal([]byte(line), &msg); err != nil {
+				t.Fatalf("%s: invalid line %q: %s", test.name, line, err)
+			}
+			if _, err := time.Parse(time.RFC3339, msg.Time); err != nil {
+				t.Errorf("%s: invalid time %q", test.name, msg.Time)
+			}
+
+			got = append(got, msg.Level+": "+msg.Msg)
+		}
+
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%s: logged %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package gasx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const overlayName = "overlay.json"
+
+// overlayJSON "go build -overlay" file format
+type overlayJSON struct {
+	Replace map[string]string
+}
+
+// OverlayFile return path of overlay file for "go build -overlay". Returns empty string if overlay mode is disabled.
+func (builder *Builder) OverlayFile() string {
+	if builder.OverlayDir == "" {
+		return ""
+	}
+
+	return filepath.Join(builder.OverlayDir, overlayName)
+}
+
+// targetPath return path where compiled file is written
+func (builder *Builder) targetPath(fileInfo File) string {
+	if builder.OverlayDir == "" {
+		return outputPath(fileInfo)
+	}
+
+	virtual := builder.hostPath(builder.outFS(), outputPath(fileInfo))
+	name := hashString(virtual)[:12] + "_" + filepath.Base(virtual)
+	if builder.outFS() == OS {
+		return filepath.Join(builder.OverlayDir, name)
+	}
+
+	return path.Join(builder.OverlayDir, name)
+}
+
+// sourceName return GOS file name for line directives and header relative to compiled file (hooks can move it).
+// Compiled files in overlay dir aren't near sources, so they need absolute path.
+func (builder *Builder) sourceName(fileInfo File, output string) string {
+	if builder.OverlayDir != "" {
+		return builder.hostPath(builder.sourceFS(), fileInfo.Path)
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(output), fileInfo.Path)
+	if err != nil {
+		return filepath.Base(fileInfo.Path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// hostPath return absolute path of file system name for go toolchain: OS names are resolved from working directory,
+// names of other file systems are joined to Builder.Root
+func (builder *Builder) hostPath(fsys fs.FS, name string) string {
+	if fsys == OS {
+		return absPath(name)
+	}
+
+	root := builder.Root
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+
+	return filepath.Join(root, filepath.FromSlash(name))
+}
+
+// outPath return OutFS name of host path built by hostPath, false if path is outside of OutFS
+func (builder *Builder) outPath(host string) (string, bool) {
+	if builder.outFS() == OS {
+		return host, true
+	}
+
+	root := builder.Root
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+
+	rel, err := filepath.Rel(root, host)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// writeOverlay add compiled files to overlay file. Entries which compiled files don't exist are removed.
+// Replace map has host paths of sources and compiled files.
+func (builder *Builder) writeOverlay(replace map[string]string) error {
+	overlay := overlayJSON{Replace: make(map[string]string)}
+
+	overlayBody, err := fs.ReadFile(builder.outFS(), builder.OverlayFile())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error while reading overlay file: %s", err.Error())
+	}
+
+	if err == nil {
+		json.Unmarshal(overlayBody, &overlay)
+		if overlay.Replace == nil {
+			overlay.Replace = make(map[string]string)
+		}
+	}
+
+	for virtual, real := range replace {
+		overlay.Replace[virtual] = real
+	}
+
+	for virtual, real := range overlay.Replace {
+		if name, ok := builder.outPath(real); !ok || !ExistsFS(builder.outFS(), name) {
+			delete(overlay.Replace, virtual)
+		}
+	}
+
+	overlayBody, err = json.MarshalIndent(overlay, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return builder.outFS().WriteFile(builder.OverlayFile(), overlayBody, 0644)
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
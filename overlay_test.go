@@ -0,0 +1,50 @@
+package gasx
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOverlayInFS(t *testing.T) {
+	tests := []struct {
+		name string
+		root string
+		want string
+	}{
+		{"without root", "", string(filepath.Separator)},
+		{"with root", filepath.FromSlash("/home/user/project"), filepath.FromSlash("/home/user/project/")},
+	}
+
+	for _, test := range tests {
+		fsys := NewMemFS(map[string]string{
+			"app/a.gos": "package app\n\nvar A = 1\n",
+		})
+		builder := &Builder{FS: fsys, Root: test.root, OverlayDir: ".overlay"}
+
+		err := builder.ParseFiles([]File{{Path: "app/a.gos", Extension: "gos"}})
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, builder.OverlayFile())
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		var overlay overlayJSON
+		json.Unmarshal(body, &overlay)
+
+		real, ok := overlay.Replace[test.want+filepath.FromSlash("app/a_gas.go")]
+		if !ok || !strings.HasPrefix(real, test.want+".overlay") {
+			t.Errorf("%s: overlay = %q", test.name, overlay.Replace)
+		}
+
+		compiled, _ := fs.ReadFile(fsys, builder.targetPath(File{Path: "app/a.gos", Extension: "gos"}))
+		if !strings.Contains(string(compiled), test.want+filepath.FromSlash("app/a.gos")) {
+			t.Errorf("%s: line directives don't point to source in root:\n%s", test.name, compiled)
+		}
+	}
+}
@@ -2,91 +2,426 @@ package gasx
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
-	"regexp"
 	"strings"
+	"sync"
 )
 
-var tRgxp = regexp.MustCompile(`\$([a-zA-Z0-9]*){((.|\s)*?)[^\\]}\$`)
-
-// ParseFiles parse and compile GOS files
+// ParseFiles parse and compile GOS files. Every file is compiled, errors are returned as BuildErrors.
 func (builder *Builder) ParseFiles(files []File) error {
-	for _, fileInfo := range files {
-		filename := strings.TrimSuffix(fileInfo.Path, "."+fileInfo.Extension) + "_gas.go"
+	cache, err := builder.loadCache()
+	if err != nil {
+		return err
+	}
 
-		// TODO: Add hook here
+	results := make([]*fileResult, len(files))
+	builder.forEachFile(files, func(i int, fileInfo File) {
+		results[i] = builder.parseFile(cache, fileInfo)
+	})
 
-		fileBytes, err := ioutil.ReadFile(fileInfo.Path)
+	if builder.OverlayDir != "" {
+		err = builder.outFS().MkdirAll(builder.OverlayDir, os.ModePerm)
 		if err != nil {
-			return fmt.Errorf("error while opening %s: \n%s", fileInfo.Path, err.Error())
+			return fmt.Errorf("error while creating overlay dir: %s", err.Error())
 		}
-		fileBody := string(fileBytes)
+	}
 
-		parsedFileBody, err := builder.CompileFile(fileInfo, fileBody)
-		if err != nil {
-			return err
+	// outputs are written in files order, so result doesn't depend on workers
+	var (
+		errs    BuildErrors
+		overlay = make(map[string]string)
+	)
+	for _, result := range results {
+		if result.err != nil {
+			errs = errs.add(result.err)
+			continue
 		}
 
-		osFile, err := os.Create(filename)
-		if err != nil {
-			if err == os.ErrPermission {
-				fmt.Println("Run: \"chmod 777 -R $GOPATH/pkg/mod\"")
+		if result.Cached {
+			for _, restore := range builder.CacheRestorers {
+				restore(result.File, result.Artifacts)
 			}
+			builder.collectArtifacts(result)
 
-			return err
+			overlay[builder.hostPath(builder.outFS(), outputPath(result.File))] = builder.hostPath(builder.outFS(), result.Output)
+
+			err = runHooks(builder.AfterWrite, result.FileContext)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			continue
 		}
 
-		_, err = osFile.Write([]byte(parsedFileBody))
+		err := builder.outFS().WriteFile(result.Output, []byte(result.Compiled), 0644)
 		if err != nil {
-			return err
+			errs = append(errs, &Diagnostic{File: result.Output, Severity: SeverityError, Message: "error while writing file: " + err.Error()})
+			continue
 		}
 
-		err = osFile.Close()
+		cache.set(result.File.Path, result.hash, result.Output, result.Compiled, result.Artifacts)
+		overlay[builder.hostPath(builder.outFS(), outputPath(result.File))] = builder.hostPath(builder.outFS(), result.Output)
+		builder.collectArtifacts(result)
+
+		err = runHooks(builder.AfterWrite, result.FileContext)
 		if err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
 
+	err = builder.removeStaleFiles()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if builder.OverlayDir != "" {
+		err = builder.writeOverlay(overlay)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err = cache.save()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
 	return nil
 }
 
+// collectArtifacts pass file artifacts to ArtifactCollectors
+func (builder *Builder) collectArtifacts(result *fileResult) {
+	for _, collect := range builder.ArtifactCollectors {
+		collect(result.File, result.Artifacts)
+	}
+}
+
+// BuildErrors errors of several files
+type BuildErrors []error
+
+func (errs BuildErrors) Error() string {
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("%d error(s): \n%s", len(errs), strings.Join(msgs, "\n"))
+}
+
+// add append error to errors list, errors lists are flattened
+func (errs BuildErrors) add(err error) BuildErrors {
+	if list, ok := err.(BuildErrors); ok {
+		return append(errs, list...)
+	}
+
+	return append(errs, err)
+}
+
+// fileResult compiled (or skipped by cache) GOS file
+type fileResult struct {
+	*FileContext
+
+	hash string
+
+	err error
+}
+
+// forEachFile run f for every file using Builder.Workers goroutines
+func (builder *Builder) forEachFile(files []File, f func(int, File)) {
+	if builder.Workers <= 1 {
+		for i, fileInfo := range files {
+			f(i, fileInfo)
+		}
+		return
+	}
+
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < builder.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f(i, files[i])
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// parseFile read, compile and run hooks for GOS file. File is always compiled if cache is nil.
+func (builder *Builder) parseFile(cache *buildCache, fileInfo File) *fileResult {
+	result := &fileResult{
+		FileContext: &FileContext{
+			File:   fileInfo,
+			Output: builder.targetPath(fileInfo),
+		},
+	}
+
+	fileBytes, err := fs.ReadFile(builder.sourceFS(), fileInfo.Path)
+	if err != nil {
+		result.err = &Diagnostic{File: fileInfo.Path, Severity: SeverityError, Message: "error while opening file: " + err.Error()}
+		return result
+	}
+	result.Source = string(fileBytes)
+
+	result.err = runHooks(builder.BeforeFile, result.FileContext)
+	if result.err != nil {
+		return result
+	}
+
+	result.hash = cache.hash(result.Source)
+	if compiled, artifacts, ok := cache.upToDate(fileInfo.Path, result.hash, result.Output); ok {
+		result.Cached = true
+		result.Compiled = compiled
+		result.Artifacts = artifacts
+		return result
+	}
+
+	result.Compiled, result.Artifacts, result.err = builder.compileFile(fileInfo, result.Source, result.Output)
+	if result.err != nil {
+		return result
+	}
+
+	result.err = runHooks(builder.AfterCompile, result.FileContext)
+	return result
+}
+
 // ParseFile compile GOS file to pure golang
 func (builder *Builder) CompileFile(fileInfo File, fileBody string) (string, error) {
-	var lenDiff int
-	matches := tRgxp.FindAllStringSubmatchIndex(fileBody, -1)
-	for _, match := range matches {
-		n := func(i int) int {
-			return i + lenDiff
-		}
+	out, _, err := builder.compileFile(fileInfo, fileBody, builder.targetPath(fileInfo))
+	return out, err
+}
 
-		var (
-			blockStart = n(match[0])
-			blockEnd   = n(match[1])
+// compileFile compile GOS file written to output and return it with compilers artifacts
+func (builder *Builder) compileFile(fileInfo File, fileBody, output string) (string, map[string]string, error) {
+	blocks, err := scanBlocks(fileInfo, fileBody)
+	if err != nil {
+		return "", nil, err
+	}
 
-			nameStart = n(match[2])
-			nameEnd   = n(match[3])
-			name      = fileBody[nameStart:nameEnd]
+	var (
+		sourceName = builder.sourceName(fileInfo, output)
+		state      = &fileState{sourceName: sourceName, artifacts: make(map[string]string)}
+		outBlocks  []outputBlock
+		errs       BuildErrors
+	)
 
-			valueStart = n(match[4])
-			valueEnd   = n(match[5])
-			value      = fileBody[valueStart:valueEnd]
+	var lenDiff int
+	src := fileBody
+	for _, block := range blocks {
+		var (
+			blockStart = block.start + lenDiff
+			blockEnd   = block.end + lenDiff
 		)
 
-		newVal, err := builder.RenderBlock(&BlockInfo{
-			Name:      string(name),
-			Value:     strings.TrimSpace(value),
-			FileInfo:  fileInfo,
-			FileBytes: fileBody,
-		})
+		newVal, err := builder.compileBlock(fileInfo, src, block, state)
 		if err != nil {
-			return "", fmt.Errorf("error while rendering block in %s (name: %s, valS: %d, valE: %d): \n%s", fileInfo.Path, name, valueStart, valueEnd, err.Error())
+			// continue to report errors of all blocks
+			errs = append(errs, err)
+			continue
 		}
 
-		lenDiff += len(newVal) - len(fileBody[blockStart:blockEnd])
+		// generated code points at the block, host code after it is resynced with the template
+		newVal = lineDirective(sourceName, src, block.start) + newVal + lineDirective(sourceName, src, block.end)
+
+		lenDiff += len(newVal) - (blockEnd - blockStart)
 
 		fileBody = fileBody[:blockStart] + newVal + fileBody[blockEnd:]
+
+		outBlocks = append(outBlocks, outputBlock{span{blockStart, blockStart + len(newVal)}, block})
 	}
 
-	return fileBody, nil
+	if len(errs) == 1 {
+		return "", nil, errs[0]
+	}
+
+	if len(errs) != 0 {
+		return "", nil, errs
+	}
+
+	fileBody, err = formatFile(fileInfo, state, src, fileBody, outBlocks)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fileBody, state.artifacts, nil
+}
+
+// outputPath return compiled file path for GOS file
+func outputPath(fileInfo File) string {
+	return strings.TrimSuffix(fileInfo.Path, "."+fileInfo.Extension) + "_gas.go"
+}
+
+// fileState values collected from blocks of compiled file
+type fileState struct {
+	sourceName string
+	artifacts  map[string]string
+
+	// imports, decls added by compilers
+	imports []Import
+	decls   []fileDecl
+}
+
+// compileBlock compile nested blocks and then block itself
+func (builder *Builder) compileBlock(fileInfo File, src string, block *blockNode, state *fileState) (string, error) {
+	var (
+		name   = src[block.nameStart:block.nameEnd]
+		value  strings.Builder
+		shifts []offsetShift
+		last   = block.valueStart
+	)
+
+	for _, child := range block.childes {
+		value.WriteString(src[last:child.start])
+
+		childVal, err := builder.compileBlock(fileInfo, src, child, state)
+		if err != nil {
+			return "", err
+		}
+
+		shifts = append(shifts, offsetShift{at: value.Len(), to: child.start, fixed: true})
+		value.WriteString(childVal)
+		shifts = append(shifts, offsetShift{at: value.Len(), to: child.end})
+
+		last = child.end
+	}
+	value.WriteString(src[last:block.valueEnd])
+
+	if diagnostic := builder.unknownBlock(name); diagnostic != nil {
+		diagnostic.File = fileInfo.Path
+		diagnostic.Line, diagnostic.Column = position(src, block.start)
+		return "", diagnostic
+	}
+
+	if diagnostic, i := builder.validateArgs(name, block.args); diagnostic != nil {
+		offset := block.start
+		if i != -1 {
+			offset = block.args[i].offset
+		}
+
+		diagnostic.File = fileInfo.Path
+		diagnostic.Line, diagnostic.Column = position(src, offset)
+		return "", diagnostic
+	}
+
+	trimmedValue := strings.TrimSpace(value.String())
+	trimmed := len(value.String()) - len(strings.TrimLeft(value.String(), " \t\r\n"))
+
+	info := &BlockInfo{
+		Name:      name,
+		Value:     trimmedValue,
+		Args:      block.args,
+		FileInfo:  fileInfo,
+		FileBytes: src,
+		Artifacts: state.artifacts,
+		lineDirective: func(offset int) string {
+			return lineDirective(state.sourceName, src, sourceOffset(block.valueStart, shifts, trimmed+offset))
+		},
+	}
+
+	newVal, err := builder.RenderBlock(info)
+	if err != nil {
+		diagnostic, ok := err.(*Diagnostic)
+		if !ok {
+			diagnostic = &Diagnostic{Message: err.Error()}
+		}
+
+		if diagnostic.File == "" {
+			diagnostic.File = fileInfo.Path
+		}
+
+		if diagnostic.Block == "" {
+			diagnostic.Block = name
+		}
+
+		if diagnostic.Severity == "" {
+			diagnostic.Severity = SeverityError
+		}
+
+		if diagnostic.Line == 0 {
+			diagnostic.Line, diagnostic.Column = position(src, sourceOffset(block.valueStart, shifts, trimmed+diagnostic.Offset))
+		}
+
+		return "", diagnostic
+	}
+
+	state.addImports(info.Imports)
+	for _, decl := range info.Decls {
+		// declaration points at the block which added it
+		line, col := position(src, block.start)
+		state.addDecl(decl, fmt.Sprintf("//line %s:%d:%d", state.sourceName, line, col))
+	}
+
+	return newVal, nil
+}
+
+// offsetShift position in block value where nested block output starts or ends
+type offsetShift struct {
+	// at offset in block value
+	at int
+
+	// to offset in GOS file
+	to int
+
+	// fixed all offsets after "at" point to "to" (inside nested block output)
+	fixed bool
+}
+
+// sourceOffset convert offset in block value to offset in GOS file
+func sourceOffset(valueStart int, shifts []offsetShift, offset int) int {
+	out := valueStart + offset
+	for _, shift := range shifts {
+		if shift.at > offset {
+			break
+		}
+
+		if shift.fixed {
+			out = shift.to
+		} else {
+			out = shift.to + offset - shift.at
+		}
+	}
+
+	return out
+}
+
+// LineDirective return inline "line" directive pointing at offset in Value. Compilers put it before code generated
+// from every template node, so positions of Go errors in block code spanning several lines point at the node.
+// Returns empty string if block isn't compiled by Builder.
+func (info *BlockInfo) LineDirective(offset int) string {
+	if info.lineDirective == nil {
+		return ""
+	}
+
+	return info.lineDirective(offset)
+}
+
+// lineDirective return inline "line" directive pointing at offset in GOS file
+func lineDirective(sourceName, src string, offset int) string {
+	line, col := position(src, offset)
+	return fmt.Sprintf("/*line %s:%d:%d*/", sourceName, line, col)
+}
+
+// position convert byte offset to 1-based line and column
+func position(src string, offset int) (int, int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+
+	before := src[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := offset - strings.LastIndex(before, "\n")
+
+	return line, col
 }
@@ -0,0 +1,100 @@
+package gasx
+
+import "sort"
+
+// Register add compiler for special blocks with names, it replaces compiler registered for the same name before.
+// Describe its configuration by AddCacheKey if cache is used.
+func (builder *Builder) Register(compiler BlockCompiler, names ...string) {
+	if builder.Compilers == nil {
+		builder.Compilers = make(map[string]BlockCompiler)
+	}
+
+	for _, name := range names {
+		builder.Compilers[name] = compiler
+	}
+}
+
+// compilersNames return sorted names of registered compilers
+func (builder *Builder) compilersNames() []string {
+	var names []string
+	for name := range builder.Compilers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// unknownBlock return diagnostic if compilers registry is used and block name isn't registered
+func (builder *Builder) unknownBlock(name string) *Diagnostic {
+	if len(builder.Compilers) == 0 {
+		return nil
+	}
+
+	if _, ok := builder.Compilers[name]; ok {
+		return nil
+	}
+
+	diagnostic := &Diagnostic{
+		Block:    name,
+		Severity: SeverityError,
+		Message:  "unknown block \"" + name + "\"",
+	}
+
+	if closest := closestName(name, builder.compilersNames()); closest != "" {
+		diagnostic.Suggestion = "did you mean \"$" + closest + "{\"?"
+	} else {
+		diagnostic.Suggestion = "register compiler for the block with Builder.Register"
+	}
+
+	return diagnostic
+}
+
+// closestName return name which is the most similar to name, empty string if names are too different
+func closestName(name string, names []string) string {
+	var (
+		closest  string
+		distance = len(name)/2 + 2 // more different names aren't typos
+	)
+	for _, other := range names {
+		if d := levenshtein(name, other); d < distance {
+			closest, distance = other, d
+		}
+	}
+
+	return closest
+}
+
+// levenshtein return edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
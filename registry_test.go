@@ -0,0 +1,105 @@
+package gasx
+
+import "testing"
+
+func TestRegister(t *testing.T) {
+	compiler := func(out string) BlockCompiler {
+		return func(info *BlockInfo) (string, error) { return out, nil }
+	}
+
+	tests := []struct {
+		name     string
+		register func(builder *Builder)
+		block    string
+		want     string
+	}{
+		{
+			name:     "single compiler",
+			register: func(builder *Builder) { builder.Register(compiler("1"), "html", "htmlF") },
+			block:    "htmlF",
+			want:     "1",
+		},
+		{
+			name: "duplicate registration replaces compiler",
+			register: func(builder *Builder) {
+				builder.Register(compiler("1"), "html")
+				builder.Register(compiler("2"), "html")
+			},
+			block: "html",
+			want:  "2",
+		},
+		{
+			name: "duplicate registration keeps other names",
+			register: func(builder *Builder) {
+				builder.Register(compiler("1"), "html", "htmlF")
+				builder.Register(compiler("2"), "html")
+			},
+			block: "htmlF",
+			want:  "1",
+		},
+	}
+
+	for _, test := range tests {
+		builder := &Builder{}
+		test.register(builder)
+
+		got, err := builder.RenderBlock(&BlockInfo{Name: test.block})
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if got != test.want {
+			t.Errorf("%s: compiled by %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestUnknownBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		block      string
+		suggestion string
+		known      bool
+	}{
+		{"no registry", nil, "hmtl", "", true},
+		{"registered", []string{"html", "htmlF"}, "htmlF", "", true},
+		{"typo", []string{"html", "htmlF"}, "hmtl", "did you mean \"$html{\"?", false},
+		{"extra letter", []string{"html", "htmlF"}, "htmlFF", "did you mean \"$htmlF{\"?", false},
+		{"different name", []string{"html"}, "markdown", "register compiler for the block with Builder.Register", false},
+	}
+
+	for _, test := range tests {
+		builder := &Builder{}
+		if len(test.names) != 0 {
+			builder.Register(func(info *BlockInfo) (string, error) { return info.Value, nil }, test.names...)
+		}
+
+		diagnostic := builder.unknownBlock(test.block)
+		if (diagnostic == nil) != test.known {
+			t.Errorf("%s: diagnostic %v, want known block %v", test.name, diagnostic, test.known)
+			continue
+		}
+
+		if diagnostic != nil && (diagnostic.Suggestion != test.suggestion || diagnostic.Block != test.block) {
+			t.Errorf("%s: block %q, suggestion %q, want %q", test.name, diagnostic.Block, diagnostic.Suggestion, test.suggestion)
+		}
+	}
+}
+
+func TestUnknownBlockPosition(t *testing.T) {
+	src := "package app\n\nvar A = $html{a}$\nvar B = $hmtl{b}$\n"
+
+	builder := &Builder{}
+	builder.Register(func(info *BlockInfo) (string, error) { return "`" + info.Value + "`", nil }, "html")
+
+	_, err := builder.CompileFile(File{Path: "app/a.gos", Extension: "gos"}, src)
+	diagnostic, ok := err.(*Diagnostic)
+	if !ok {
+		t.Fatalf("error %v isn't diagnostic", err)
+	}
+
+	if diagnostic.File != "app/a.gos" || diagnostic.Line != 4 || diagnostic.Column != 9 {
+		t.Errorf("position %s:%d:%d, want app/a.gos:4:9", diagnostic.File, diagnostic.Line, diagnostic.Column)
+	}
+}
@@ -0,0 +1,225 @@
+package gasx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command process started by Run
+type Command struct {
+	// Name program name or path
+	Name string
+
+	// Args program arguments
+	Args []string
+
+	// Dir working directory, current one if empty
+	Dir string
+
+	// Env environment overrides ("KEY=value"), added to the current process environment
+	Env []string
+
+	// Stdout, Stderr process output streams, os.Stdout and os.Stderr if nil. Use ioutil.Discard to hide output.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Prefix added to every line of streamed output (e.g. "[wasm] ")
+	Prefix string
+
+	// Capture save process output in CommandResult
+	Capture bool
+}
+
+// Shell return command running line by "sh -c"
+func Shell(line string) *Command {
+	return &Command{Name: "sh", Args: []string{"-c", line}}
+}
+
+func (command *Command) String() string {
+	return strings.Join(append([]string{command.Name}, command.Args...), " ")
+}
+
+// CommandResult information about finished process
+type CommandResult struct {
+	// ExitCode process exit code, -1 if process wasn't started or was killed by signal
+	ExitCode int
+
+	// Signal signal which killed process, empty if process exited by itself
+	Signal string
+
+	// Duration process running time
+	Duration time.Duration
+
+	// Stdout, Stderr process output if Command.Capture is true
+	Stdout string
+	Stderr string
+}
+
+// ExitError process failed, canceled or exited with non-zero code
+type ExitError struct {
+	Command string
+	Result  *CommandResult
+
+	// Err underlying error (context error for canceled processes)
+	Err error
+}
+
+func (err *ExitError) Error() string {
+	msg := fmt.Sprintf("command %q failed: %s", err.Command, err.Err.Error())
+	if stderr := strings.TrimSpace(err.Result.Stderr); stderr != "" {
+		msg += "\n" + stderr
+	}
+
+	return msg
+}
+
+func (err *ExitError) Unwrap() error {
+	return err.Err
+}
+
+// Run start command and wait for it. The whole process group is killed when ctx is done.
+// Process gets its own group only if ctx can be canceled, otherwise terminal signals (Ctrl-C) reach it as usual.
+// Returns *ExitError if process fails to start, exits with non-zero code or is canceled.
+func Run(ctx context.Context, command *Command) (*CommandResult, error) {
+	cmd := exec.Command(command.Name, command.Args...)
+	cmd.Dir = command.Dir
+	if len(command.Env) != 0 {
+		cmd.Env = append(os.Environ(), command.Env...)
+	}
+
+	if ctx.Done() != nil {
+		setProcessGroup(cmd)
+	}
+
+	var (
+		stdout, stderr bytes.Buffer
+		mu             sync.Mutex // prefixed streams can share writer
+	)
+
+	outStream := newLineWriter(outputOr(command.Stdout, os.Stdout), command.Prefix, &mu)
+	errStream := newLineWriter(outputOr(command.Stderr, os.Stderr), command.Prefix, &mu)
+	cmd.Stdout, cmd.Stderr = outStream, errStream
+	if command.Capture {
+		cmd.Stdout = io.MultiWriter(outStream, &stdout)
+		cmd.Stderr = io.MultiWriter(errStream, &stderr)
+	}
+
+	start := time.Now()
+	err := cmd.Start()
+	if err == nil {
+		err = wait(ctx, cmd)
+	}
+	outStream.flush()
+	errStream.flush()
+
+	result := &CommandResult{
+		ExitCode: -1,
+		Duration: time.Since(start),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+		result.Signal = exitSignal(cmd.ProcessState)
+	}
+
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && result.Signal == "" {
+			err = fmt.Errorf("exit code %d", result.ExitCode)
+		}
+
+		return result, &ExitError{Command: command.String(), Result: result, Err: err}
+	}
+
+	return result, nil
+}
+
+// wait wait for started process, its group is killed when ctx is done
+func wait(ctx context.Context, cmd *exec.Cmd) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+func outputOr(out, def io.Writer) io.Writer {
+	if out == nil {
+		return def
+	}
+
+	return out
+}
+
+// lineWriter writer adding prefix to every line
+type lineWriter struct {
+	out    io.Writer
+	prefix string
+	mu     *sync.Mutex
+	line   []byte // unfinished line
+}
+
+func newLineWriter(out io.Writer, prefix string, mu *sync.Mutex) *lineWriter {
+	return &lineWriter{out: out, prefix: prefix, mu: mu}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.prefix == "" {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		return w.out.Write(p)
+	}
+
+	w.line = append(w.line, p...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i == -1 {
+			break
+		}
+
+		err := w.writeLine(w.line[:i+1])
+		w.line = w.line[i+1:]
+		if err != nil {
+			return len(p), err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *lineWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
+
+// flush write unfinished line
+func (w *lineWriter) flush() {
+	if len(w.line) != 0 {
+		w.writeLine(append(w.line, '\n'))
+		w.line = nil
+	}
+}
@@ -0,0 +1,58 @@
+package gasx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *Command
+		stdout   string
+		exitCode int
+		err      bool
+	}{
+		{"success", &Command{Name: "sh", Args: []string{"-c", "echo a; echo b"}}, "a\nb\n", 0, false},
+		{"prefix", &Command{Name: "sh", Args: []string{"-c", "echo a; printf b"}, Prefix: "[x] "}, "[x] a\n[x] b\n", 0, false},
+		{"exit code", &Command{Name: "sh", Args: []string{"-c", "exit 3"}}, "", 3, true},
+		{"not found", &Command{Name: "gasx-unknown-command"}, "", -1, true},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		test.command.Stdout, test.command.Stderr = &out, ioutil.Discard
+
+		result, err := Run(context.Background(), test.command)
+		if (err != nil) != test.err {
+			t.Errorf("%s: error %v, want error %v", test.name, err, test.err)
+			continue
+		}
+
+		if result.ExitCode != test.exitCode || out.String() != test.stdout {
+			t.Errorf("%s: exit code %d, output %q, want %d, %q", test.name, result.ExitCode, out.String(), test.exitCode, test.stdout)
+		}
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := Run(ctx, &Command{Name: "sh", Args: []string{"-c", "sleep 30"}, Stdout: ioutil.Discard})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want deadline exceeded", err)
+	}
+
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("process isn't killed on cancel")
+	}
+	if result.ExitCode != -1 {
+		t.Errorf("exit code %d, want -1", result.ExitCode)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package gasx
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup start process in its own group, so it can be killed with its children
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kill process with its children
+func killProcessGroup(cmd *exec.Cmd) error {
+	err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	if err == syscall.ESRCH {
+		return os.ErrProcessDone
+	}
+
+	return err
+}
+
+// exitSignal return name of signal which killed process
+func exitSignal(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	return status.Signal().String()
+}
@@ -0,0 +1,107 @@
+//go:build !windows
+// +build !windows
+
+package gasx
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// pidWriter send pid printed by process
+type pidWriter chan int
+
+func (w pidWriter) Write(p []byte) (int, error) {
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(p))); err == nil {
+		w <- pid
+	}
+
+	return len(p), nil
+}
+
+func TestRunProcessGroup(t *testing.T) {
+	cancelable, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		ownGroup bool
+	}{
+		{"background context", context.Background(), false},
+		{"cancelable context", cancelable, true},
+	}
+
+	for _, test := range tests {
+		pids := make(pidWriter, 1)
+		group := make(chan int, 1)
+		go func() {
+			pid := <-pids
+			pgid, _ := syscall.Getpgid(pid)
+			group <- pgid
+		}()
+
+		_, err := Run(test.ctx, &Command{Name: "sh", Args: []string{"-c", "echo $$; sleep 0.2"}, Stdout: pids, Stderr: ioutil.Discard})
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if ownGroup := <-group != syscall.Getpgrp(); ownGroup != test.ownGroup {
+			t.Errorf("%s: process has own group = %v", test.name, ownGroup)
+		}
+	}
+}
+
+func TestRunKillGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// background child of shell must be killed with it
+	pids := make(pidWriter, 1)
+	done := make(chan error, 1)
+	go func() {
+		_, err := Run(ctx, &Command{Name: "sh", Args: []string{"-c", "sleep 30 & echo $!; wait"}, Stdout: pids, Stderr: ioutil.Discard})
+		done <- err
+	}()
+
+	child := <-pids
+	cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected error for canceled process")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run isn't finished after cancel: child holds output")
+	}
+
+	for i := 0; i < 100; i++ {
+		if !processAlive(child) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("child process %d is alive after cancel", child)
+}
+
+// processAlive return false if process doesn't exist or is a zombie (killed orphans can be not reaped in containers)
+func processAlive(pid int) bool {
+	if syscall.Kill(pid, 0) == syscall.ESRCH {
+		return false
+	}
+
+	stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+
+	// "pid (comm) state ..."
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package gasx
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup processes groups are not supported on windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kill process (without its children on windows)
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// exitSignal processes aren't killed by signals on windows
+func exitSignal(state *os.ProcessState) string {
+	return ""
+}
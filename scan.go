@@ -0,0 +1,315 @@
+package gasx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// blockNode special block position in GOS file
+type blockNode struct {
+	// start, end whole block "$name{ ... }$"
+	start, end int
+
+	nameStart, nameEnd   int
+	valueStart, valueEnd int
+
+	// args arguments "$name(key=value){ ... }$"
+	args []BlockArg
+
+	// childes nested blocks
+	childes []*blockNode
+
+	// expressions Go code from template ("{{ }}" and attributes values)
+	expressions []span
+}
+
+// span range in GOS file
+type span struct {
+	start, end int
+}
+
+type blockScanner struct {
+	fileInfo File
+	src      string
+	i        int
+}
+
+// scanBlocks find special blocks in GOS file body
+func scanBlocks(fileInfo File, src string) ([]*blockNode, error) {
+	s := &blockScanner{fileInfo: fileInfo, src: src}
+
+	blocks, _, err := s.scanGo(false)
+	return blocks, err
+}
+
+func (s *blockScanner) errorf(offset int, format string, a ...interface{}) error {
+	line, col := position(s.src, offset)
+	return &Diagnostic{
+		File:     s.fileInfo.Path,
+		Line:     line,
+		Column:   col,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
+
+func (s *blockScanner) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(s.src[s.i:], prefix)
+}
+
+// skipUntil move scanner after end (or to EOF if there is no end). Returns false if there is no end.
+func (s *blockScanner) skipUntil(end string) bool {
+	i := strings.Index(s.src[s.i:], end)
+	if i == -1 {
+		s.i = len(s.src)
+		return false
+	}
+
+	s.i += i + len(end)
+	return true
+}
+
+// skipLiteral move scanner after comment or raw string starting at scanner position.
+// Inside blocks unterminated literal is error at its start, otherwise Go compiler reports it.
+func (s *blockScanner) skipLiteral(start, end, name string, inBlock bool) error {
+	offset := s.i
+	s.i += len(start)
+	if !s.skipUntil(end) && inBlock {
+		return s.errorf(offset, "unterminated %s", name)
+	}
+
+	return nil
+}
+
+// skipQuoted move scanner after quoted literal with backslash escapes. Returns false if literal isn't closed.
+func (s *blockScanner) skipQuoted(quote byte, multiline bool) bool {
+	s.i++
+	for s.i < len(s.src) {
+		switch s.src[s.i] {
+		case '\\':
+			s.i += 2
+			continue
+		case quote:
+			s.i++
+			return true
+		case '\n':
+			if !multiline {
+				s.i++
+				return false
+			}
+		}
+		s.i++
+	}
+
+	s.i = len(s.src)
+	return false
+}
+
+// scanGo scan Go code. In expression mode (inside "{{ }}") scanner stops after closing "}}".
+// Returns false if end of expression wasn't found.
+func (s *blockScanner) scanGo(expression bool) ([]*blockNode, bool, error) {
+	var (
+		blocks []*blockNode
+		depth  int
+	)
+
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case s.hasPrefix("//"):
+			s.skipUntil("\n")
+		case s.hasPrefix("/*"):
+			if err := s.skipLiteral("/*", "*/", "comment", expression); err != nil {
+				return nil, false, err
+			}
+		case c == '"' || c == '\'':
+			s.skipQuoted(c, false)
+		case c == '`':
+			if err := s.skipLiteral("`", "`", "raw string", expression); err != nil {
+				return nil, false, err
+			}
+		case c == '$':
+			block, err := s.scanBlock()
+			if err != nil {
+				return nil, false, err
+			}
+
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		case expression && c == '{':
+			depth++
+			s.i++
+		case expression && c == '}':
+			if depth == 0 && s.hasPrefix("}}") {
+				s.i += 2
+				return blocks, true, nil
+			}
+
+			if depth > 0 {
+				depth--
+			}
+			s.i++
+		default:
+			s.i++
+		}
+	}
+
+	return blocks, !expression, nil
+}
+
+// scanBlock scan special block starting at "$". Returns nil if it's not a block.
+func (s *blockScanner) scanBlock() (*blockNode, error) {
+	block := &blockNode{start: s.i, nameStart: s.i + 1}
+
+	s.i++
+	for s.i < len(s.src) && isBlockNameChar(s.src[s.i]) {
+		s.i++
+	}
+	block.nameEnd = s.i
+
+	if s.i < len(s.src) && s.src[s.i] == '(' {
+		argsEnd, quote := s.argsEnd()
+		if quote != -1 {
+			err := s.errorf(quote, "unterminated quoted value").(*Diagnostic)
+			err.Block = s.src[block.nameStart:block.nameEnd]
+			return nil, err
+		}
+		if argsEnd == -1 {
+			return nil, nil
+		}
+
+		if argsEnd+1 >= len(s.src) || s.src[argsEnd+1] != '{' {
+			return nil, nil
+		}
+
+		args, offset, msg := parseBlockArgs(s.src, s.i+1, argsEnd)
+		if msg != "" {
+			err := s.errorf(offset, "%s", msg).(*Diagnostic)
+			err.Block = s.src[block.nameStart:block.nameEnd]
+			return nil, err
+		}
+
+		block.args = args
+		s.i = argsEnd + 1
+	}
+
+	if s.i >= len(s.src) || s.src[s.i] != '{' {
+		return nil, nil
+	}
+
+	s.i++
+	block.valueStart = s.i
+
+	for s.i < len(s.src) {
+		switch c := s.src[s.i]; {
+		case s.hasPrefix(`\}$`):
+			s.i += 3
+		case s.hasPrefix("}$"):
+			block.valueEnd = s.i
+			s.i += 2
+			block.end = s.i
+			return block, nil
+		case s.hasPrefix("{{"):
+			s.i += 2
+			exprStart := s.i
+			childes, ok, err := s.scanGo(true)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				return nil, s.unterminated(block)
+			}
+
+			block.childes = append(block.childes, childes...)
+			block.expressions = append(block.expressions, span{exprStart, s.i - 2})
+		case s.hasPrefix("<!--"):
+			if err := s.skipLiteral("<!--", "-->", "comment", true); err != nil {
+				return nil, err
+			}
+		case (c == '"' || c == '\'') && s.afterEqualSign():
+			exprStart := s.i + 1
+			s.skipQuoted(c, true)
+			block.expressions = append(block.expressions, span{exprStart, s.i - 1})
+		case c == '`' && s.afterEqualSign():
+			exprStart := s.i + 1
+			if err := s.skipLiteral("`", "`", "raw string", true); err != nil {
+				return nil, err
+			}
+			block.expressions = append(block.expressions, span{exprStart, s.i - 1})
+		case c == '$':
+			child, err := s.scanBlock()
+			if err != nil {
+				return nil, err
+			}
+
+			if child != nil {
+				block.childes = append(block.childes, child)
+			}
+		default:
+			s.i++
+		}
+	}
+
+	return nil, s.unterminated(block)
+}
+
+func (s *blockScanner) unterminated(block *blockNode) error {
+	name := s.src[block.nameStart:block.nameEnd]
+
+	err := s.errorf(block.start, "unterminated block \"%s\"", name).(*Diagnostic)
+	err.Block = name
+	err.Suggestion = "close the block with \"}$\""
+
+	return err
+}
+
+// argsEnd return offset of ")" closing block arguments, -1 if there is no one.
+// Second value is offset of unterminated quote in arguments, -1 if quotes are closed.
+func (s *blockScanner) argsEnd() (int, int) {
+	start := s.i
+	defer func() { s.i = start }()
+
+	for s.i++; s.i < len(s.src); {
+		quote := s.i
+		switch c := s.src[s.i]; c {
+		case '"':
+			if !s.skipQuoted(c, false) {
+				return -1, quote
+			}
+		case '`', '\'':
+			s.i++
+			if !s.skipUntil(string(c)) {
+				return -1, quote
+			}
+		case ')':
+			return s.i, -1
+		case '(', '{', '}', ';':
+			return -1, -1
+		default:
+			s.i++
+		}
+	}
+
+	return -1, -1
+}
+
+// afterEqualSign return true if previous non space char is "=" (quote opens attribute value)
+func (s *blockScanner) afterEqualSign() bool {
+	for i := s.i - 1; i >= 0; i-- {
+		switch s.src[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '=':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func isBlockNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
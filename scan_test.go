@@ -0,0 +1,159 @@
+package gasx
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestScanBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+
+		// want blocks as "name(args){value}" with nested blocks names after ":"
+		want []string
+
+		// err error as "line:column: message"
+		err string
+	}{
+		{
+			name: "block",
+			src:  "var a = $html{<div></div>}$",
+			want: []string{"html{<div></div>}"},
+		},
+		{
+			name: "arguments",
+			src:  "var a = $html(root=div, title='a) b'){<p></p>}$",
+			want: []string{"html(root=div,title=a) b){<p></p>}"},
+		},
+		{
+			name: "nested blocks",
+			src:  "var a = $html{<div>{{ $htmlEl{<p></p>}$ }}</div>}$",
+			want: []string{"html{<div>{{ $htmlEl{<p></p>}$ }}</div>}:htmlEl"},
+		},
+		{
+			name: "blocks in strings and comments are skipped",
+			src:  "// $html{a}$\n/* $html{b}$ */\nvar a = \"$html{c}$\" + `$html{d}$` + '$'",
+			want: nil,
+		},
+		{
+			name: "escaped end",
+			src:  "var a = $html{<p>\\}$</p>}$",
+			want: []string{"html{<p>\\}$</p>}"},
+		},
+		{
+			name: "attribute values and html comments",
+			src:  "var a = $html{<p title=\"}$\" data-x=`}$`><!-- }$ --></p>}$",
+			want: []string{"html{<p title=\"}$\" data-x=`}$`><!-- }$ --></p>}"},
+		},
+		{
+			name: "expression with braces and literals",
+			src:  "var a = $html{<p>{{ map[string]int{\"}}\": 1} /* }} */ }}</p>}$",
+			want: []string{"html{<p>{{ map[string]int{\"}}\": 1} /* }} */ }}</p>}"},
+		},
+		{
+			name: "not a block",
+			src:  "var a = \"x\" + $ + $html(a b",
+			want: nil,
+		},
+		{
+			name: "unterminated block",
+			src:  "var a = 1\nvar b = $html{<div>",
+			err:  "2:9: unterminated block \"html\"",
+		},
+		{
+			name: "unterminated expression",
+			src:  "var b = $html{<div>{{ a }</div>}$",
+			err:  "1:9: unterminated block \"html\"",
+		},
+		{
+			name: "unterminated comment in expression",
+			src:  "var b = $html{<div>\n{{ a /* b }}</div>}$",
+			err:  "2:6: unterminated comment",
+		},
+		{
+			name: "unterminated raw string in expression",
+			src:  "var b = $html{<div>{{ `a }}</div>}$",
+			err:  "1:23: unterminated raw string",
+		},
+		{
+			name: "unterminated html comment",
+			src:  "var b = $html{<div>\n  <!-- a </div>}$",
+			err:  "2:3: unterminated comment",
+		},
+		{
+			name: "unterminated raw attribute value",
+			src:  "var b = $html{<div class=`a></div>}$",
+			err:  "1:26: unterminated raw string",
+		},
+		{
+			name: "unterminated comment outside block",
+			src:  "var b = $html{<div></div>}$ /* a",
+			want: []string{"html{<div></div>}"},
+		},
+		{
+			name: "invalid arguments",
+			src:  "var b = $html(a=1, 2=b){<div></div>}$",
+			err:  "1:20: invalid argument name \"2\"",
+		},
+		{
+			name: "unterminated quoted argument",
+			src:  "var b = $html(a=1, b=\"x){<div></div>}$",
+			err:  "1:22: unterminated quoted value",
+		},
+	}
+
+	for _, test := range tests {
+		blocks, err := scanBlocks(File{Path: "app.gos"}, test.src)
+		if test.err != "" {
+			diagnostic, ok := err.(*Diagnostic)
+			if !ok {
+				t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+				continue
+			}
+
+			if got := diagnosticPosition(diagnostic); got != test.err {
+				t.Errorf("%s: error %q, want %q", test.name, got, test.err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		var got []string
+		for _, block := range blocks {
+			got = append(got, describeBlock(test.src, block))
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s:\ngot  %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+// describeBlock return block as "name(args){value}" with nested blocks names after ":"
+func describeBlock(src string, block *blockNode) string {
+	out := src[block.nameStart:block.nameEnd]
+	if len(block.args) != 0 {
+		var args []string
+		for _, arg := range block.args {
+			args = append(args, arg.Key+"="+arg.Value)
+		}
+		out += "(" + strings.Join(args, ",") + ")"
+	}
+	out += "{" + src[block.valueStart:block.valueEnd] + "}"
+
+	for _, child := range block.childes {
+		out += ":" + src[child.nameStart:child.nameEnd]
+	}
+
+	return out
+}
+
+func diagnosticPosition(diagnostic *Diagnostic) string {
+	return strings.Join([]string{strconv.Itoa(diagnostic.Line), strconv.Itoa(diagnostic.Column), " " + diagnostic.Message}, ":")
+}
@@ -4,7 +4,8 @@ import (
 	"os"
 	"fmt"
 	"strings"
-	"io/ioutil"
+	"io/fs"
+	"path"
 	"go/build"
 	"github.com/visualfc/fastmod"
 )
@@ -21,25 +22,9 @@ func GrepStyles() ([]string, error) {
 
 // GrepStylesCustom grep styles files path in deps (for custom dir) by their styles.gas
 func GrepStylesCustom(dir string, already map[string]bool) ([]string, error) {
-	var stylesOut []string
-
-	stylesGasFile, err := ioutil.ReadFile(dir+"/styles.gas")
-	if err != nil && !os.IsNotExist(err) {
-		return []string{}, fmt.Errorf("error opening \"styles.gas\": \"%s\"", err.Error())
-	}
-
-	patterns := strings.Split(string(stylesGasFile), "\n")
-	if len(patterns) > 0 && patterns[0] == "" || patterns[0] == " " {
-		patterns = []string{}
-	}
-	
-	for _, pattern := range patterns {
-		paths, err := FilesByPattern(dir, pattern)
-		if err != nil {
-			return []string{}, err
-		}
-
-		stylesOut = append(stylesOut, paths...)
+	stylesOut, err := GrepStylesFS(OS, dir)
+	if err != nil {
+		return []string{}, err
 	}
 
 	pkg, err := fastmod.LoadPackage(dir, &build.Default)
@@ -65,3 +50,28 @@ func GrepStylesCustom(dir string, already map[string]bool) ([]string, error) {
 	return stylesOut, nil
 }
 
+// GrepStylesFS grep styles files path in directory of fsys by its styles.gas (without deps)
+func GrepStylesFS(fsys fs.FS, dir string) ([]string, error) {
+	var stylesOut []string
+
+	stylesGasFile, err := fs.ReadFile(fsys, path.Join(dir, "styles.gas"))
+	if err != nil && !os.IsNotExist(err) {
+		return []string{}, fmt.Errorf("error opening \"styles.gas\": \"%s\"", err.Error())
+	}
+
+	patterns := strings.Split(string(stylesGasFile), "\n")
+	if len(patterns) > 0 && patterns[0] == "" || patterns[0] == " " {
+		patterns = []string{}
+	}
+	
+	for _, pattern := range patterns {
+		paths, err := FilesByPatternFS(fsys, dir, pattern)
+		if err != nil {
+			return []string{}, err
+		}
+
+		stylesOut = append(stylesOut, paths...)
+	}
+
+	return stylesOut, nil
+}
@@ -0,0 +1,234 @@
+package gasx
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"sort"
+	"strconv"
+)
+
+// tinyGoImports packages which TinyGo doesn't support on wasm target
+var tinyGoImports = map[string]string{
+	"text/template": "it uses reflect.Value.Call",
+	"html/template": "it uses reflect.Value.Call",
+	"net/rpc":       "it uses reflect.Value.Call",
+	"encoding/gob":  "it needs full reflect support",
+	"plugin":        "plugins aren't supported",
+}
+
+// tinyGoReflectFuncs reflect package functions which TinyGo doesn't implement
+var tinyGoReflectFuncs = []string{"MakeFunc", "FuncOf", "StructOf"}
+
+// tinyGoReflectMethods reflect.Value and reflect.Type methods which TinyGo doesn't implement.
+// Files aren't type checked, so only calls on values which come from reflect functions are reported.
+var tinyGoReflectMethods = []string{"Call", "CallSlice", "MethodByName"}
+
+// reflectValueFuncs reflect package functions returning reflect.Value or reflect.Type
+var reflectValueFuncs = []string{"ValueOf", "TypeOf", "Indirect", "New", "Zero", "Append", "MakeSlice", "MakeMap", "PtrTo", "PointerTo", "SliceOf", "MapOf"}
+
+// reflectValueMethods reflect.Value and reflect.Type methods returning reflect.Value or reflect.Type
+var reflectValueMethods = []string{"Elem", "Field", "FieldByIndex", "FieldByName", "Index", "MapIndex", "Method", "MethodByName", "Addr", "Convert", "Slice", "Type", "In", "Out", "Key"}
+
+// TinyGoDiagnostics read compiled GOS files and return constructs incompatible with TinyGo as warnings.
+// Positions point to GOS files. Files which can't be read or parsed are skipped, go compiler reports them.
+func (builder *Builder) TinyGoDiagnostics(files []File) []*Diagnostic {
+	var diagnostics []*Diagnostic
+	for _, fileInfo := range files {
+		compiled, err := fs.ReadFile(builder.outFS(), builder.targetPath(fileInfo))
+		if err != nil {
+			continue
+		}
+
+		diagnostics = append(diagnostics, TinyGoFileDiagnostics(fileInfo.Path, string(compiled))...)
+	}
+
+	return diagnostics
+}
+
+// TinyGoFileDiagnostics return constructs of compiled file incompatible with TinyGo, file is GOS file path
+func TinyGoFileDiagnostics(file, compiled string) []*Diagnostic {
+	fset := token.NewFileSet()
+	goFile, err := parser.ParseFile(fset, file, compiled, 0)
+	if err != nil {
+		return nil
+	}
+
+	var diagnostics []*Diagnostic
+	report := func(pos token.Pos, suggestion, format string, args ...interface{}) {
+		// line directives make position point to GOS file
+		position := fset.Position(pos)
+		diagnostics = append(diagnostics, &Diagnostic{
+			File:       file,
+			Line:       position.Line,
+			Column:     position.Column,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf(format, args...),
+			Suggestion: suggestion,
+		})
+	}
+
+	reflectName := ""
+	for _, imp := range goFile.Imports {
+		importPath, _ := strconv.Unquote(imp.Path.Value)
+
+		var name string
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		name = importName(name, importPath)
+		if importPath == "reflect" {
+			reflectName = name
+		}
+
+		if reason, ok := tinyGoImports[importPath]; ok {
+			report(imp.Pos(), "", "package %q isn't supported by TinyGo: %s", importPath, reason)
+		}
+	}
+
+	if reflectName == "" || reflectName == "_" {
+		return diagnostics
+	}
+
+	reflection := &reflectValues{pkg: reflectName, vars: make(map[interface{}]bool)}
+	reflection.collect(goFile)
+
+	ast.Inspect(goFile, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if reflection.isPkg(selector.X) {
+			if InArrayString(selector.Sel.Name, tinyGoReflectFuncs) {
+				report(selector.Pos(), "", "reflect.%s isn't supported by TinyGo", selector.Sel.Name)
+			}
+			return true
+		}
+
+		if InArrayString(selector.Sel.Name, tinyGoReflectMethods) && reflection.is(selector.X) {
+			report(selector.Sel.Pos(), "use direct calls or type switches instead of reflection",
+				"reflect %s isn't supported by TinyGo", selector.Sel.Name)
+		}
+
+		return true
+	})
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+
+	return diagnostics
+}
+
+// reflectValues finds expressions of reflect.Value and reflect.Type without type checking:
+// results of reflect functions, their methods and variables assigned from them or declared with reflect types
+type reflectValues struct {
+	// pkg reflect package name in file
+	pkg string
+
+	// vars variables by objects (or names if identifier isn't resolved)
+	vars map[interface{}]bool
+}
+
+// collect find reflect variables, assignments are checked until nothing changes, so their order doesn't matter
+func (values *reflectValues) collect(file *ast.File) {
+	for changed := true; changed; {
+		changed = false
+		mark := func(ident *ast.Ident) {
+			if ident.Name != "_" && !values.vars[values.key(ident)] {
+				values.vars[values.key(ident)] = true
+				changed = true
+			}
+		}
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.AssignStmt:
+				if len(node.Lhs) != len(node.Rhs) {
+					return true
+				}
+				for i, lhs := range node.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok && values.is(node.Rhs[i]) {
+						mark(ident)
+					}
+				}
+			case *ast.ValueSpec:
+				for i, name := range node.Names {
+					if values.isType(node.Type) || i < len(node.Values) && values.is(node.Values[i]) {
+						mark(name)
+					}
+				}
+			case *ast.Field:
+				if values.isType(node.Type) {
+					for _, name := range node.Names {
+						mark(name)
+						// struct field in selector
+						values.vars[name.Name] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// key return identifier object, identifiers of the same variable have the same object
+func (values *reflectValues) key(ident *ast.Ident) interface{} {
+	if ident.Obj != nil {
+		return ident.Obj
+	}
+
+	return ident.Name
+}
+
+// isPkg return true if expression is reflect package name
+func (values *reflectValues) isPkg(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == values.pkg && ident.Obj == nil
+}
+
+// isType return true if expression is reflect.Value or reflect.Type type or slice of them
+func (values *reflectValues) isType(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ArrayType:
+		return values.isType(expr.Elt)
+	case *ast.SelectorExpr:
+		return values.isPkg(expr.X) && (expr.Sel.Name == "Value" || expr.Sel.Name == "Type")
+	}
+
+	return false
+}
+
+// is return true if expression is reflect.Value or reflect.Type
+func (values *reflectValues) is(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return values.is(expr.X)
+	case *ast.Ident:
+		return values.vars[values.key(expr)]
+	case *ast.IndexExpr:
+		return values.is(expr.X)
+	case *ast.SelectorExpr:
+		// struct fields aren't resolved, they are found by names
+		return values.vars[values.key(expr.Sel)]
+	case *ast.CallExpr:
+		selector, ok := expr.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+
+		if values.isPkg(selector.X) {
+			return InArrayString(selector.Sel.Name, reflectValueFuncs)
+		}
+
+		return InArrayString(selector.Sel.Name, reflectValueMethods) && values.is(selector.X)
+	}
+
+	return false
+}
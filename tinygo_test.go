@@ -0,0 +1,75 @@
+package gasx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTinyGoFileDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []tinyGoWarning
+	}{
+		{
+			name: "unsupported packages",
+			src:  "import (\n\t\"encoding/gob\"\n\t\"fmt\"\n)\n",
+			want: []tinyGoWarning{{2, "package \"encoding/gob\""}},
+		},
+		{
+			name: "reflect functions",
+			src:  "import r \"reflect\"\n\nvar T = r.StructOf(nil)\n",
+			want: []tinyGoWarning{{3, "reflect.StructOf"}},
+		},
+		{
+			name: "method of reflect value",
+			src:  "import \"reflect\"\n\nfunc f(x interface{}) {\n\tv := reflect.ValueOf(x)\n\tv.MethodByName(\"Run\").Call(nil)\n}\n",
+			want: []tinyGoWarning{{5, "reflect MethodByName"}, {5, "reflect Call"}},
+		},
+		{
+			name: "reflect parameters and fields",
+			src:  "import \"reflect\"\n\ntype s struct {\n\tfn reflect.Value\n}\n\nfunc f(v reflect.Value, x s) {\n\tv.Call(nil)\n\tx.fn.CallSlice(nil)\n}\n",
+			want: []tinyGoWarning{{8, "reflect Call"}, {9, "reflect CallSlice"}},
+		},
+		{
+			name: "assignment chain",
+			src:  "import \"reflect\"\n\nfunc f(x interface{}) {\n\tvar t reflect.Type\n\tt = reflect.TypeOf(x).Elem()\n\tm := t\n\tm.MethodByName(\"Run\")\n}\n",
+			want: []tinyGoWarning{{7, "reflect MethodByName"}},
+		},
+		{
+			name: "other receivers",
+			src:  "import \"reflect\"\n\nvar T = reflect.TypeOf(0)\n\nfunc f(c client) {\n\tc.Call(\"method\")\n\tc.MethodByName(\"x\")\n}\n",
+		},
+		{
+			name: "without reflect import",
+			src:  "func f(v value) {\n\tv.Call(nil)\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		diagnostics := TinyGoFileDiagnostics("app.gos", "package app\n\n"+test.src)
+
+		var got []string
+		for _, diagnostic := range diagnostics {
+			got = append(got, diagnostic.Error())
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("%s: diagnostics:\n%s\nwant %q", test.name, strings.Join(got, "\n"), test.want)
+			continue
+		}
+
+		for i, want := range test.want {
+			// lines of test source follow package clause
+			if diagnostics[i].Line != want.line+2 || !strings.HasPrefix(diagnostics[i].Message, want.message) {
+				t.Errorf("%s: diagnostic %d = %s, want %d: %s", test.name, i, got[i], want.line, want.message)
+			}
+		}
+	}
+}
+
+// tinyGoWarning expected warning: line in test source and message prefix
+type tinyGoWarning struct {
+	line    int
+	message string
+}
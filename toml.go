@@ -0,0 +1,440 @@
+package gasx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlParser parser of TOML subset used by config files: tables, dotted keys, strings,
+// integers, floats, booleans, arrays and inline tables. Arrays of tables and dates aren't supported.
+type tomlParser struct {
+	src  string
+	i    int
+	line int
+
+	// lines keys lines by dotted key paths
+	lines map[string]int
+}
+
+// tomlError TOML syntax error
+type tomlError struct {
+	Line    int
+	Message string
+}
+
+func (err *tomlError) Error() string {
+	return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+}
+
+// parseTOML parse TOML document to tables (map[string]interface{}) with key lines
+func parseTOML(src string) (map[string]interface{}, map[string]int, error) {
+	p := &tomlParser{src: src, line: 1, lines: make(map[string]int)}
+
+	root := make(map[string]interface{})
+	table, tablePath := root, ""
+	defined := make(map[string]bool)
+	for {
+		p.skipSpace(true)
+		if p.i >= len(p.src) {
+			break
+		}
+
+		if p.src[p.i] == '[' {
+			if strings.HasPrefix(p.src[p.i:], "[[") {
+				return nil, nil, p.errorf("arrays of tables aren't supported")
+			}
+
+			p.i++
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			p.skipSpace(false)
+			if p.i >= len(p.src) || p.src[p.i] != ']' {
+				return nil, nil, p.errorf("expected \"]\" after table name")
+			}
+			p.i++
+
+			tablePath = strings.Join(keys, ".")
+			if defined[tablePath] {
+				return nil, nil, p.errorf("table %q is defined twice", tablePath)
+			}
+			defined[tablePath] = true
+			p.lines[tablePath] = p.line
+
+			table, err = p.subTable(root, keys, "")
+			if err != nil {
+				return nil, nil, err
+			}
+		} else {
+			err := p.parseKeyValue(table, tablePath)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		err := p.endLine()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return root, p.lines, nil
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return &tomlError{Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// skipSpace skip spaces and comments, and newlines if multiline
+func (p *tomlParser) skipSpace(multiline bool) {
+	for p.i < len(p.src) {
+		switch p.src[p.i] {
+		case ' ', '\t', '\r':
+			p.i++
+		case '\n':
+			if !multiline {
+				return
+			}
+			p.line++
+			p.i++
+		case '#':
+			for p.i < len(p.src) && p.src[p.i] != '\n' {
+				p.i++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endLine check there is nothing but comment till the end of line
+func (p *tomlParser) endLine() error {
+	p.skipSpace(false)
+	if p.i < len(p.src) && p.src[p.i] != '\n' {
+		return p.errorf("unexpected %q after value", p.src[p.i])
+	}
+
+	return nil
+}
+
+// parseKey parse dotted key: bare, "quoted" or 'literal' parts
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace(false)
+		if p.i >= len(p.src) {
+			return nil, p.errorf("expected key")
+		}
+
+		switch p.src[p.i] {
+		case '"', '\'':
+			key, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		default:
+			start := p.i
+			for p.i < len(p.src) && isBareKeyChar(p.src[p.i]) {
+				p.i++
+			}
+			if start == p.i {
+				return nil, p.errorf("invalid key character %q", p.src[p.i])
+			}
+			keys = append(keys, p.src[start:p.i])
+		}
+
+		p.skipSpace(false)
+		if p.i >= len(p.src) || p.src[p.i] != '.' {
+			return keys, nil
+		}
+		p.i++
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseKeyValue parse "key = value" and set it in table
+func (p *tomlParser) parseKeyValue(table map[string]interface{}, tablePath string) error {
+	line := p.line
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	p.skipSpace(false)
+	if p.i >= len(p.src) || p.src[p.i] != '=' {
+		return p.errorf("expected \"=\" after key %q", strings.Join(keys, "."))
+	}
+	p.i++
+	p.skipSpace(false)
+
+	fullPath := joinKey(tablePath, strings.Join(keys, "."))
+	value, err := p.parseValue(fullPath)
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.subTable(table, keys[:len(keys)-1], tablePath)
+	if err != nil {
+		return err
+	}
+
+	key := keys[len(keys)-1]
+	if _, ok := parent[key]; ok {
+		return &tomlError{Line: line, Message: fmt.Sprintf("key %q is defined twice", fullPath)}
+	}
+
+	parent[key] = value
+	p.lines[fullPath] = line
+	return nil
+}
+
+// subTable return nested table by keys, tables are created if necessary
+func (p *tomlParser) subTable(table map[string]interface{}, keys []string, tablePath string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		tablePath = joinKey(tablePath, key)
+
+		value, ok := table[key]
+		if !ok {
+			sub := make(map[string]interface{})
+			table[key] = sub
+			table = sub
+			continue
+		}
+
+		sub, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, p.errorf("key %q isn't a table", tablePath)
+		}
+		table = sub
+	}
+
+	return table, nil
+}
+
+func (p *tomlParser) parseValue(path string) (interface{}, error) {
+	if p.i >= len(p.src) {
+		return nil, p.errorf("expected value")
+	}
+
+	switch c := p.src[p.i]; {
+	case c == '"' || c == '\'':
+		return p.parseString()
+	case c == '[':
+		return p.parseArray(path)
+	case c == '{':
+		return p.parseInlineTable(path)
+	case strings.HasPrefix(p.src[p.i:], "true"):
+		p.i += len("true")
+		return true, nil
+	case strings.HasPrefix(p.src[p.i:], "false"):
+		p.i += len("false")
+		return false, nil
+	default:
+		return p.parseNumber()
+	}
+}
+
+func (p *tomlParser) parseNumber() (interface{}, error) {
+	start := p.i
+	for p.i < len(p.src) && strings.IndexByte("+-0123456789_.eExobabcdfABCDF", p.src[p.i]) != -1 {
+		p.i++
+	}
+
+	value := strings.Replace(p.src[start:p.i], "_", "", -1)
+	if value == "" {
+		return nil, p.errorf("invalid value starting with %q", p.src[start])
+	}
+
+	if integer, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return integer, nil
+	}
+
+	if float, err := strconv.ParseFloat(value, 64); err == nil {
+		return float, nil
+	}
+
+	return nil, p.errorf("invalid number %q", value)
+}
+
+// parseString parse basic ("...") or literal ('...') string, both can be multiline (tripled quotes)
+func (p *tomlParser) parseString() (string, error) {
+	quote := p.src[p.i]
+	multiline := strings.HasPrefix(p.src[p.i:], strings.Repeat(string(quote), 3))
+
+	delimiter := string(quote)
+	if multiline {
+		delimiter = strings.Repeat(delimiter, 3)
+		p.i += 3
+		// newline after opening delimiter is trimmed
+		if strings.HasPrefix(p.src[p.i:], "\r\n") {
+			p.i += 2
+			p.line++
+		} else if strings.HasPrefix(p.src[p.i:], "\n") {
+			p.i++
+			p.line++
+		}
+	} else {
+		p.i++
+	}
+
+	out := strings.Builder{}
+	for {
+		if p.i >= len(p.src) {
+			return "", p.errorf("unterminated string")
+		}
+
+		if strings.HasPrefix(p.src[p.i:], delimiter) {
+			p.i += len(delimiter)
+			return out.String(), nil
+		}
+
+		c := p.src[p.i]
+		switch {
+		case c == '\n':
+			if !multiline {
+				return "", p.errorf("newline in string")
+			}
+			p.line++
+		case c == '\\' && quote == '"':
+			r, err := p.parseEscape()
+			if err != nil {
+				return "", err
+			}
+			out.WriteRune(r)
+			continue
+		}
+
+		out.WriteByte(c)
+		p.i++
+	}
+}
+
+func (p *tomlParser) parseEscape() (rune, error) {
+	if p.i+1 >= len(p.src) {
+		return 0, p.errorf("unterminated string")
+	}
+
+	c := p.src[p.i+1]
+	p.i += 2
+	switch c {
+	case 'b':
+		return '\b', nil
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'f':
+		return '\f', nil
+	case 'r':
+		return '\r', nil
+	case '"':
+		return '"', nil
+	case '\\':
+		return '\\', nil
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.i+size > len(p.src) {
+			return 0, p.errorf("invalid unicode escape")
+		}
+
+		code, err := strconv.ParseUint(p.src[p.i:p.i+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, p.errorf("invalid unicode escape \"\\%c%s\"", c, p.src[p.i:p.i+size])
+		}
+		p.i += size
+		return rune(code), nil
+	default:
+		return 0, p.errorf("invalid escape \"\\%c\"", c)
+	}
+}
+
+// parseArray parse array, values can be on several lines, trailing comma is allowed
+func (p *tomlParser) parseArray(path string) ([]interface{}, error) {
+	p.i++ // [
+
+	array := []interface{}{}
+	for {
+		p.skipSpace(true)
+		if p.i >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+
+		if p.src[p.i] == ']' {
+			p.i++
+			return array, nil
+		}
+
+		itemPath, line := fmt.Sprintf("%s[%d]", path, len(array)), p.line
+		value, err := p.parseValue(itemPath)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		p.lines[itemPath] = line
+
+		p.skipSpace(true)
+		if p.i < len(p.src) && p.src[p.i] == ',' {
+			p.i++
+			continue
+		}
+
+		if p.i >= len(p.src) || p.src[p.i] != ']' {
+			return nil, p.errorf("expected \",\" or \"]\" in array")
+		}
+	}
+}
+
+// parseInlineTable parse one line table: {key = value, ...}
+func (p *tomlParser) parseInlineTable(path string) (map[string]interface{}, error) {
+	p.i++ // {
+
+	table := make(map[string]interface{})
+	for {
+		p.skipSpace(false)
+		if p.i >= len(p.src) {
+			return nil, p.errorf("unterminated inline table")
+		}
+
+		if p.src[p.i] == '}' && len(table) == 0 {
+			p.i++
+			return table, nil
+		}
+
+		err := p.parseKeyValue(table, path)
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace(false)
+		if p.i < len(p.src) && p.src[p.i] == ',' {
+			p.i++
+			continue
+		}
+
+		if p.i >= len(p.src) || p.src[p.i] != '}' {
+			return nil, p.errorf("expected \",\" or \"}\" in inline table")
+		}
+		p.i++
+		return table, nil
+	}
+}
+
+// joinKey join dotted key path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
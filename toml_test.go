@@ -0,0 +1,82 @@
+package gasx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]interface{}
+		lines   map[string]int
+		errLine int
+	}{
+		{
+			name:  "key values",
+			src:   "# comment\nname = \"app\" # comment\nworkers = 4\nratio = 0.5\nbuild = true\n",
+			want:  map[string]interface{}{"name": "app", "workers": int64(4), "ratio": 0.5, "build": true},
+			lines: map[string]int{"name": 2, "workers": 3, "ratio": 4, "build": 5},
+		},
+		{
+			name:  "tables and dotted keys",
+			src:   "[output]\ndir = \"dist\"\n\n[acss.breakpoints]\nmd = \"(min-width: 768px)\"\nwasm.compiler = 'tinygo'\n",
+			want:  map[string]interface{}{"output": map[string]interface{}{"dir": "dist"}, "acss": map[string]interface{}{"breakpoints": map[string]interface{}{"md": "(min-width: 768px)", "wasm": map[string]interface{}{"compiler": "tinygo"}}}},
+			lines: map[string]int{"output": 1, "output.dir": 2, "acss.breakpoints": 4, "acss.breakpoints.md": 5, "acss.breakpoints.wasm.compiler": 6},
+		},
+		{
+			name:  "arrays",
+			src:   "sources = [\n  \"app\",\n  \"lib\", # comment\n]\nempty = []\n",
+			want:  map[string]interface{}{"sources": []interface{}{"app", "lib"}, "empty": []interface{}{}},
+			lines: map[string]int{"sources": 1, "sources[0]": 2, "sources[1]": 3, "empty": 5},
+		},
+		{
+			name: "inline table",
+			src:  "loader = {fallback = false, text = \"Loading\"}\nempty = {}\n",
+			want: map[string]interface{}{"loader": map[string]interface{}{"fallback": false, "text": "Loading"}, "empty": map[string]interface{}{}},
+		},
+		{
+			name: "strings",
+			src:  "basic = \"a\\tb\\u00e9\"\nliteral = 'C:\\dir'\nmulti = \"\"\"\nline1\nline2\"\"\"\n",
+			want: map[string]interface{}{"basic": "a\tbé", "literal": `C:\dir`, "multi": "line1\nline2"},
+		},
+		{name: "duplicated key", src: "a = 1\n\na = 2\n", errLine: 3},
+		{name: "duplicated table", src: "[a]\n[a]\n", errLine: 2},
+		{name: "array of tables", src: "\n[[a]]\n", errLine: 2},
+		{name: "missing equal sign", src: "a 1\n", errLine: 1},
+		{name: "unterminated string", src: "a = \"b\n", errLine: 1},
+		{name: "unterminated array", src: "a = [\n1,\n", errLine: 3},
+		{name: "invalid number", src: "a = 1x\n", errLine: 1},
+		{name: "value after value", src: "a = 1 b = 2\n", errLine: 1},
+		{name: "key isn't table", src: "a = 1\na.b = 2\n", errLine: 2},
+	}
+
+	for _, test := range tests {
+		got, lines, err := parseTOML(test.src)
+		if test.errLine != 0 {
+			tomlErr, ok := err.(*tomlError)
+			if !ok {
+				t.Errorf("%s: expected error, got %v", test.name, err)
+			} else if tomlErr.Line != test.errLine {
+				t.Errorf("%s: error line = %d, want %d (%s)", test.name, tomlErr.Line, test.errLine, tomlErr.Message)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s:\ngot  %#v\nwant %#v", test.name, got, test.want)
+		}
+
+		for key, line := range test.lines {
+			if lines[key] != line {
+				t.Errorf("%s: line of %q = %d, want %d", test.name, key, lines[key], line)
+			}
+		}
+	}
+}
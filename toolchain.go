@@ -0,0 +1,330 @@
+package gasx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CompilerGo standard go toolchain
+	CompilerGo = "go"
+
+	// CompilerTinyGo TinyGo compiler, produces smaller binaries but supports only part of reflect and std packages
+	CompilerTinyGo = "tinygo"
+)
+
+var goReleaseRgxp = regexp.MustCompile(`go1\.(\d+)`)
+
+// gojsMinor minor version of the first Go release which wasm binaries import "gojs" module instead of "go"
+const gojsMinor = 21
+
+// execScripts embedded wasm_exec.js scripts by Go release, they are used if GOROOT doesn't contain the script.
+// Release without embedded script uses the nearest older one.
+var execScripts = map[string]string{
+	"go1.13": execScript,
+	"go1.21": execScriptGo121,
+	"go1.27": execScriptGo127,
+}
+
+// execScriptPaths wasm_exec.js paths in GOROOT (lib/wasm since Go 1.24)
+var execScriptPaths = []string{
+	filepath.Join("lib", "wasm", "wasm_exec.js"),
+	filepath.Join("misc", "wasm", "wasm_exec.js"),
+}
+
+// GoToolchain go command used for build
+type GoToolchain struct {
+	// Bin go command
+	Bin string
+
+	// Version full version ("go1.21.3")
+	Version string
+
+	// Root GOROOT (TINYGOROOT for TinyGo)
+	Root string
+
+	// TinyGo toolchain is TinyGo
+	TinyGo bool
+}
+
+// LookupToolchain return toolchain of compiler (CompilerGo or CompilerTinyGo, CompilerGo if empty) used in dir
+func LookupToolchain(ctx context.Context, compiler, dir string) (*GoToolchain, error) {
+	switch compiler {
+	case "", CompilerGo:
+		return LookupGo(ctx, "", dir)
+	case CompilerTinyGo:
+		return LookupTinyGo(ctx, "", dir)
+	default:
+		return nil, fmt.Errorf("unknown compiler %q, use %q or %q", compiler, CompilerGo, CompilerTinyGo)
+	}
+}
+
+// LookupGo return toolchain of go command used in dir (go.mod toolchain directive can select another one)
+func LookupGo(ctx context.Context, bin, dir string) (*GoToolchain, error) {
+	if bin == "" {
+		bin = "go"
+	}
+
+	root, err := Run(ctx, &Command{Name: bin, Args: []string{"env", "GOROOT"}, Dir: dir, Stdout: ioutil.Discard, Capture: true})
+	if err != nil {
+		return nil, fmt.Errorf("error while looking up GOROOT: %s", err.Error())
+	}
+
+	// "go version go1.21.3 linux/amd64", "go env GOVERSION" doesn't exist before Go 1.16
+	version, err := Run(ctx, &Command{Name: bin, Args: []string{"version"}, Dir: dir, Stdout: ioutil.Discard, Capture: true})
+	if err != nil {
+		return nil, fmt.Errorf("error while looking up go version: %s", err.Error())
+	}
+
+	fields := strings.Fields(version.Stdout)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected \"go version\" output: %q", version.Stdout)
+	}
+
+	return &GoToolchain{Bin: bin, Version: fields[2], Root: strings.TrimSpace(root.Stdout)}, nil
+}
+
+// LookupTinyGo return TinyGo toolchain used in dir
+func LookupTinyGo(ctx context.Context, bin, dir string) (*GoToolchain, error) {
+	if bin == "" {
+		bin = CompilerTinyGo
+	}
+
+	root, err := Run(ctx, &Command{Name: bin, Args: []string{"env", "TINYGOROOT"}, Dir: dir, Stdout: ioutil.Discard, Capture: true})
+	if err != nil {
+		return nil, fmt.Errorf("error while looking up TINYGOROOT: %s", err.Error())
+	}
+
+	// "tinygo version 0.30.0 linux/amd64 (using go version go1.21.3 and LLVM version 16.0.1)"
+	version, err := Run(ctx, &Command{Name: bin, Args: []string{"version"}, Dir: dir, Stdout: ioutil.Discard, Capture: true})
+	if err != nil {
+		return nil, fmt.Errorf("error while looking up tinygo version: %s", err.Error())
+	}
+
+	fields := strings.Fields(version.Stdout)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected \"tinygo version\" output: %q", version.Stdout)
+	}
+
+	return &GoToolchain{Bin: bin, Version: fields[2], Root: strings.TrimSpace(root.Stdout), TinyGo: true}, nil
+}
+
+// Release return Go release ("go1.21") of toolchain version
+func (toolchain *GoToolchain) Release() string {
+	return goReleaseRgxp.FindString(toolchain.Version)
+}
+
+// ExecScript return wasm_exec.js matching toolchain: from GOROOT if it is there, otherwise embedded one for the same Go release.
+// TinyGo script differs from Go one and isn't embedded, it is always read from TINYGOROOT.
+func (toolchain *GoToolchain) ExecScript() (string, error) {
+	if toolchain.TinyGo {
+		scriptPath := filepath.Join(toolchain.Root, "targets", "wasm_exec.js")
+		script, err := ioutil.ReadFile(scriptPath)
+		if err != nil {
+			return "", fmt.Errorf("wasm_exec.js for tinygo %s isn't found: %s", toolchain.Version, err.Error())
+		}
+
+		return string(script), nil
+	}
+
+	for _, scriptPath := range execScriptPaths {
+		script, err := ioutil.ReadFile(filepath.Join(toolchain.Root, scriptPath))
+		if err == nil {
+			return string(script), nil
+		}
+	}
+
+	release := embeddedRelease(toolchain.Release())
+	if release == "" {
+		return "", fmt.Errorf("wasm_exec.js for %s isn't found in GOROOT %q and there is no embedded one for this or older release (embedded: %s)",
+			toolchain.Version, toolchain.Root, strings.Join(embeddedReleases(), ", "))
+	}
+
+	// script of older release doesn't provide import module of newer binaries
+	embeddedMinor, _ := releaseMinor(release)
+	minor, _ := releaseMinor(toolchain.Release())
+	if (embeddedMinor >= gojsMinor) != (minor >= gojsMinor) {
+		return "", fmt.Errorf("wasm_exec.js for %s isn't found in GOROOT %q and embedded one from %s has another import module",
+			toolchain.Version, toolchain.Root, release)
+	}
+
+	if release != toolchain.Release() {
+		LogLevel(LevelWarn, fmt.Sprintf("wasm_exec.js isn't found in GOROOT %q, embedded one from %s is used for %s", toolchain.Root, release, toolchain.Version))
+	}
+
+	return execScripts[release], nil
+}
+
+// embeddedRelease return the newest embedded release which isn't newer than release, empty string if there is no one
+func embeddedRelease(release string) string {
+	minor, ok := releaseMinor(release)
+	if !ok {
+		return ""
+	}
+
+	var (
+		nearest      string
+		nearestMinor = -1
+	)
+	for embedded := range execScripts {
+		embeddedMinor, _ := releaseMinor(embedded)
+		if embeddedMinor <= minor && embeddedMinor > nearestMinor {
+			nearest, nearestMinor = embedded, embeddedMinor
+		}
+	}
+
+	return nearest
+}
+
+// releaseMinor return minor version of release ("go1.21" -> 21)
+func releaseMinor(release string) (int, bool) {
+	match := goReleaseRgxp.FindStringSubmatch(release)
+	if match == nil {
+		return 0, false
+	}
+
+	minor, err := strconv.Atoi(match[1])
+	return minor, err == nil
+}
+
+func embeddedReleases() []string {
+	var releases []string
+	for release := range execScripts {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		a, _ := releaseMinor(releases[i])
+		b, _ := releaseMinor(releases[j])
+		return a < b
+	})
+
+	return releases
+}
+
+// CheckExecScript return error if wasm binary imports modules which exec script doesn't provide
+// (e.g. binary is built by Go 1.21+ and script is from older Go release)
+func CheckExecScript(wasm []byte, script string) error {
+	modules, err := wasmImportModules(wasm)
+	if err != nil {
+		return fmt.Errorf("error while reading wasm binary: %s", err.Error())
+	}
+
+	for _, module := range modules {
+		provided := regexp.MustCompile(`["']?\b` + regexp.QuoteMeta(module) + `\b["']?\s*:\s*\{`).MatchString(script)
+		if !provided {
+			return fmt.Errorf("wasm binary imports module %q which wasm_exec.js doesn't provide: script doesn't match Go version used for build", module)
+		}
+	}
+
+	return nil
+}
+
+// wasmImportModules return modules names from wasm binary import section
+func wasmImportModules(wasm []byte) ([]string, error) {
+	if len(wasm) < 8 || !bytes.Equal(wasm[:4], []byte("\x00asm")) {
+		return nil, errors.New("not a wasm binary")
+	}
+
+	r := &wasmReader{b: wasm, i: 8}
+	for r.i < len(r.b) {
+		id := r.byte()
+		size := int(r.uint())
+		if r.err != nil || r.i+size > len(r.b) {
+			return nil, errors.New("invalid section")
+		}
+
+		if id != 2 { // import section
+			r.i += size
+			continue
+		}
+
+		var (
+			modules []string
+			seen    = make(map[string]bool)
+		)
+		count := r.uint()
+		for n := uint64(0); n < count && r.err == nil; n++ {
+			module := r.name()
+			r.name() // field
+			switch r.byte() {
+			case 0: // func
+				r.uint()
+			case 1: // table
+				r.byte()
+				r.limits()
+			case 2: // memory
+				r.limits()
+			case 3: // global
+				r.byte()
+				r.byte()
+			default:
+				return nil, errors.New("invalid import kind")
+			}
+
+			if !seen[module] {
+				seen[module] = true
+				modules = append(modules, module)
+			}
+		}
+
+		return modules, r.err
+	}
+
+	return nil, nil
+}
+
+// wasmReader reader of wasm binary values
+type wasmReader struct {
+	b   []byte
+	i   int
+	err error
+}
+
+func (r *wasmReader) byte() byte {
+	if r.i >= len(r.b) {
+		r.err = errors.New("unexpected end of binary")
+		return 0
+	}
+
+	r.i++
+	return r.b[r.i-1]
+}
+
+// uint read LEB128 unsigned integer
+func (r *wasmReader) uint() uint64 {
+	value, n := binary.Uvarint(r.b[r.i:])
+	if n <= 0 {
+		r.err = errors.New("invalid integer")
+		return 0
+	}
+
+	r.i += n
+	return value
+}
+
+func (r *wasmReader) name() string {
+	size := int(r.uint())
+	if r.err != nil || r.i+size > len(r.b) {
+		r.err = errors.New("invalid name")
+		return ""
+	}
+
+	r.i += size
+	return string(r.b[r.i-size : r.i])
+}
+
+func (r *wasmReader) limits() {
+	if r.byte() == 1 { // with maximum
+		r.uint()
+	}
+	r.uint()
+}
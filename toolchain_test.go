@@ -0,0 +1,92 @@
+package gasx
+
+import "testing"
+
+func TestEmbeddedRelease(t *testing.T) {
+	tests := []struct {
+		release string
+		want    string
+	}{
+		{"go1.13", "go1.13"},
+		{"go1.20", "go1.13"},
+		{"go1.21", "go1.21"},
+		{"go1.26", "go1.21"},
+		{"go1.27", "go1.27"},
+		{"go1.30", "go1.27"},
+		{"go1.12", ""},
+		{"devel", ""},
+	}
+
+	for _, test := range tests {
+		if got := embeddedRelease(test.release); got != test.want {
+			t.Errorf("embeddedRelease(%q) = %q, want %q", test.release, got, test.want)
+		}
+	}
+}
+
+func TestExecScriptFallback(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		err     bool
+	}{
+		{"go1.13.8", execScript, false},
+		{"go1.20.14", execScript, false},
+		{"go1.21.3", execScriptGo121, false},
+		{"go1.26.1", execScriptGo121, false},
+		{"go1.27.1", execScriptGo127, false},
+		{"go1.10", "", true},
+	}
+
+	for _, test := range tests {
+		toolchain := &GoToolchain{Version: test.version, Root: t.TempDir()}
+		script, err := toolchain.ExecScript()
+		if (err != nil) != test.err {
+			t.Errorf("%s: error %v, want error %v", test.version, err, test.err)
+			continue
+		}
+
+		if script != test.want {
+			t.Errorf("%s: unexpected embedded script", test.version)
+		}
+	}
+
+	// release of gojs module can't use script of older one
+	delete(execScripts, "go1.21")
+	defer func() { execScripts["go1.21"] = execScriptGo121 }()
+
+	toolchain := &GoToolchain{Version: "go1.22.0", Root: t.TempDir()}
+	if _, err := toolchain.ExecScript(); err == nil {
+		t.Errorf("expected error for go1.13 script used for go1.22")
+	}
+}
+
+func TestWasmImportModules(t *testing.T) {
+	// header, import section: 2 imports ("gojs"."a" func 0, "gojs"."b" memory {0})
+	wasm := []byte("\x00asm\x01\x00\x00\x00" +
+		"\x02\x14\x02" +
+		"\x04gojs\x01a\x00\x00" +
+		"\x04gojs\x01b\x02\x00\x00")
+
+	modules, err := wasmImportModules(wasm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modules) != 1 || modules[0] != "gojs" {
+		t.Errorf("modules = %q, want [gojs]", modules)
+	}
+
+	if err := CheckExecScript(wasm, execScript); err == nil {
+		t.Errorf("go1.13 script must not provide gojs module")
+	}
+	if err := CheckExecScript(wasm, execScriptGo121); err != nil {
+		t.Errorf("go1.21 script must provide gojs module: %s", err)
+	}
+	if err := CheckExecScript(wasm, execScriptGo127); err != nil {
+		t.Errorf("go1.27 script must provide gojs module: %s", err)
+	}
+
+	if _, err := wasmImportModules([]byte("not wasm")); err == nil {
+		t.Errorf("expected error for invalid binary")
+	}
+}
@@ -0,0 +1,181 @@
+package gasx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// compilerErrorRgxp "file:line:col: message" line of compiler output
+var compilerErrorRgxp = regexp.MustCompile(`^(\S[^:]*):(\d+)(?::(\d+))?: (.+)$`)
+
+// WasmBuild "go build" (or "tinygo build") of application main package for js/wasm
+type WasmBuild struct {
+	// Main directory of main package
+	Main string
+
+	// Compiler CompilerGo or CompilerTinyGo, CompilerGo if empty
+	Compiler string
+
+	// Output wasm binary path
+	Output string
+
+	// Tags build tags
+	Tags []string
+
+	// LDFlags linker flags ("-s -w")
+	LDFlags string
+
+	// TrimPath remove file system paths from binary, TinyGo binary is built without debug information
+	TrimPath bool
+
+	// Overlay "go build -overlay" file (Builder.OverlayFile), TinyGo doesn't support overlays
+	Overlay string
+
+	// Env environment overrides ("KEY=value"), GOOS and GOARCH are always js and wasm
+	Env []string
+
+	// Log stream for compiler output, output is only returned in errors if nil
+	Log io.Writer
+}
+
+// Args return "go build" arguments
+func (build *WasmBuild) Args() []string {
+	if build.Compiler == CompilerTinyGo {
+		return build.tinyGoArgs()
+	}
+
+	args := []string{"build", "-o", absPath(build.Output)}
+	if len(build.Tags) != 0 {
+		args = append(args, "-tags", strings.Join(build.Tags, ","))
+	}
+	if build.LDFlags != "" {
+		args = append(args, "-ldflags", build.LDFlags)
+	}
+	if build.TrimPath {
+		args = append(args, "-trimpath")
+	}
+	if build.Overlay != "" {
+		args = append(args, "-overlay", absPath(build.Overlay))
+	}
+
+	return append(args, ".")
+}
+
+// tinyGoArgs return "tinygo build" arguments
+func (build *WasmBuild) tinyGoArgs() []string {
+	args := []string{"build", "-target", "wasm", "-o", absPath(build.Output)}
+	if len(build.Tags) != 0 {
+		args = append(args, "-tags", strings.Join(build.Tags, " "))
+	}
+	if build.LDFlags != "" {
+		args = append(args, "-ldflags", build.LDFlags)
+	}
+	if build.TrimPath {
+		args = append(args, "-no-debug")
+	}
+
+	return append(args, ".")
+}
+
+// Build run compiler. Compiler errors are returned as Diagnostics (BuildErrors),
+// other failures as *ExitError.
+func (build *WasmBuild) Build(ctx context.Context) error {
+	err := os.MkdirAll(filepath.Dir(build.Output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	log := build.Log
+	if log == nil {
+		log = ioutil.Discard
+	}
+
+	command := &Command{
+		Name:    CompilerGo,
+		Args:    build.Args(),
+		Dir:     build.Main,
+		Env:     append(append([]string{}, build.Env...), "GOOS=js", "GOARCH=wasm"),
+		Stdout:  log,
+		Stderr:  log,
+		Capture: true,
+	}
+	switch build.Compiler {
+	case "", CompilerGo:
+	case CompilerTinyGo:
+		if build.Overlay != "" {
+			return errors.New("tinygo doesn't support overlay, disable overlay mode to build with tinygo")
+		}
+
+		// target is set by "-target wasm"
+		command.Name, command.Env = CompilerTinyGo, build.Env
+	default:
+		return fmt.Errorf("unknown compiler %q, use %q or %q", build.Compiler, CompilerGo, CompilerTinyGo)
+	}
+	command.Prefix = "[" + command.Name + "] "
+
+	result, err := Run(ctx, command)
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil || result == nil {
+		return err
+	}
+
+	diagnostics := CompilerDiagnostics(build.Main, result.Stderr)
+	if len(diagnostics) == 0 {
+		return err
+	}
+
+	var errs BuildErrors
+	for _, diagnostic := range diagnostics {
+		errs = append(errs, diagnostic)
+	}
+
+	return errs
+}
+
+// CompilerDiagnostics parse "file:line:col: message" errors from go compiler output.
+// Relative files are relative to dir. Line directives in compiled files make positions point to GOS files.
+func CompilerDiagnostics(dir, output string) []*Diagnostic {
+	var diagnostics []*Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		// details of the previous error
+		if strings.HasPrefix(line, "\t") && len(diagnostics) != 0 {
+			last := diagnostics[len(diagnostics)-1]
+			last.Message += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		match := compilerErrorRgxp.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+
+		file := match[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		lineNumber, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+
+		diagnostics = append(diagnostics, &Diagnostic{
+			File:     file,
+			Line:     lineNumber,
+			Column:   column,
+			Severity: SeverityError,
+			Message:  match[4],
+		})
+	}
+
+	return diagnostics
+}
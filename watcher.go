@@ -1,15 +1,30 @@
 package gasx
 
 import (
+	"context"
 	"os"
 	"strings"
-	"log"
 	"time"
 	"github.com/radovskyb/watcher"
 )
 
+// watchInterval default files polling interval
+const watchInterval = 3 * time.Second
+
+// StartWatcher watch files changes, files are checked every 3s
 func StartWatcher(onUpdate func(name string), ignoringExt, watchings, recursiveWatchings []string) error {
+	return StartWatcherInterval(watchInterval, onUpdate, ignoringExt, watchings, recursiveWatchings)
+}
+
+// StartWatcherInterval watch files changes, files are checked every interval
+func StartWatcherInterval(interval time.Duration, onUpdate func(name string), ignoringExt, watchings, recursiveWatchings []string) error {
+	return StartWatcherContext(context.Background(), interval, onUpdate, ignoringExt, watchings, recursiveWatchings)
+}
+
+// StartWatcherContext watch files changes until ctx is done, files are checked every interval
+func StartWatcherContext(ctx context.Context, interval time.Duration, onUpdate func(name string), ignoringExt, watchings, recursiveWatchings []string) error {
 	w := watcher.New()
+	defer w.Close()
 
 	w.SetMaxEvents(1)
 	w.FilterOps(watcher.Rename, watcher.Move, watcher.Write, watcher.Create, watcher.Remove)
@@ -40,13 +55,16 @@ func StartWatcher(onUpdate func(name string), ignoringExt, watchings, recursiveW
 				}
 				onUpdate(event.Path)
 			case err := <-w.Error:
-				log.Fatalln(err)
+				LogError(err)
+			case <-ctx.Done():
+				w.Close()
+				return
 			case <-w.Closed:
 				return
 			}
 		}
 	}()
 
-	// Start the watching process - it'll check for changes every 3s.
-	return w.Start(time.Millisecond * 3000)
+	// Start the watching process - it'll check for changes every interval.
+	return w.Start(interval)
 }
\ No newline at end of file